@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	pkgReport "github.com/willjunx/go-coverage-report/pkg/report"
+)
+
+const checkUsage = `
+	Usage: %s check [OPTIONS] [OLD_COVERAGE_FILE] <NEW_COVERAGE_FILE>
+
+	Check the coverage against the thresholds of the configuration without rendering
+	a report, exiting with a non-zero status when a check fails. With both coverage
+	files, the changed files are checked like by the diff command; with the new one
+	only, every file is checked like by the summary command.
+
+	OPTIONS:
+`
+
+func (c *cli) runCheck(args []string) error {
+	fs := c.newFlagSet("check", checkUsage)
+	opts := newOptions(fs)
+	args, err := parseArgs(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+
+	if len(args) == 1 {
+		cov, err := loadCoverage(conf, args[0])
+		if err != nil {
+			return err
+		}
+
+		failures = pkgReport.NewSummary(&conf, cov).Failures()
+	} else {
+		result, err := compare(conf, args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		if !result.Empty() {
+			failures = result.Failures()
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("coverage check failed: %s", strings.Join(failures, ", "))
+	}
+
+	log.Println("Coverage check passed")
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+)
+
+const configEnv = config.EnvPrefix + "CONFIG"
+
+// loadConfig merges the configuration sources, with flags taking precedence
+// over the environment, the environment over the file and the file over the
+// defaults. The modules of the workspace are added when enabled.
+func loadConfig(opts options) (config.Config, error) {
+	conf := config.Default
+
+	path, err := configPath(opts.configPath)
+	if err != nil {
+		return conf, err
+	}
+
+	if path != "" {
+		if err := config.FromFile(&conf, path); err != nil {
+			return conf, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+
+	if err := config.ApplyEnv(&conf, os.LookupEnv); err != nil {
+		return conf, fmt.Errorf("invalid environment: %w", err)
+	}
+
+	if err := config.Apply(&conf, opts.values); err != nil {
+		return conf, fmt.Errorf("invalid flag: %w", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return conf, err
+	}
+
+	if conf.Workspace {
+		if err := workspaceModules(&conf); err != nil {
+			return conf, err
+		}
+	}
+
+	return conf, nil
+}
+
+func configPath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+
+	if v := os.Getenv(configEnv); v != "" {
+		return v, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := config.Discover(wd)
+	if err != nil {
+		return "", fmt.Errorf("failed to discover config: %w", err)
+	}
+
+	return path, nil
+}
+
+const configUsage = `
+	Usage: %s config validate [CONFIG_FILE...]
+
+	Validate the configuration files: unknown fields, invalid thresholds, symbols,
+	outputs and exclude patterns are reported with their position in the file.
+	Without CONFIG_FILE, the file is resolved like for a report run. Exits with a
+	non-zero status if any file is invalid, which makes it usable as pre-commit hook.
+`
+
+func (c *cli) runConfig(args []string) error {
+	fs := c.newFlagSet("config", configUsage)
+	args, err := parseArgs(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	if args[0] != "validate" {
+		fs.Usage()
+		return fmt.Errorf("unknown config command %q", args[0])
+	}
+
+	files := args[1:]
+	if len(files) == 0 {
+		path, err := configPath("")
+		if err != nil {
+			return err
+		}
+
+		if path == "" {
+			return errors.New("no configuration file found")
+		}
+
+		files = []string{path}
+	}
+
+	var errs []error
+
+	for _, file := range files {
+		if err := validateConfigFile(file); err != nil {
+			errs = append(errs, fmt.Errorf("%s is invalid:\n%w", file, err))
+			continue
+		}
+
+		log.Printf("%s is valid", file)
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateConfigFile(path string) error {
+	conf := config.Default
+	if err := config.ValidateFile(&conf, path); err != nil {
+		return err
+	}
+
+	_, err := parseOutputs(conf)
+
+	return err
+}
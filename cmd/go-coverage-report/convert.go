@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+	pkgReport "github.com/willjunx/go-coverage-report/pkg/report"
+)
+
+const convertUsage = `
+	Usage: %s convert [OPTIONS] <COVERAGE_FILE>
+
+	Convert the COVERAGE_FILE, which may be compressed or read from stdin as -, to
+	another format: 'profile' writes it back as a plain go test -coverprofile file,
+	'json' writes the statements of each file and 'cobertura' the Cobertura XML
+	report. The result is written to stdout unless -o is given.
+
+	OPTIONS:
+`
+
+// formatProfile is the go test -coverprofile format of the convert command.
+const formatProfile = "profile"
+
+func (c *cli) runConvert(args []string) error {
+	fs := c.newFlagSet("convert", convertUsage)
+	format := fs.String("to", pkgReport.FormatCobertura, "output `format`: 'profile', 'json' or 'cobertura'")
+	out := fs.String("o", stdoutPath, "`path` of the converted file, - for stdout")
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	cov, err := coverage.NewCoverageFromFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	var write func(w io.Writer) error
+
+	switch strings.ToLower(*format) {
+	case formatProfile:
+		write = cov.WriteProfile
+	case pkgReport.FormatJSON:
+		write = func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "    ")
+
+			return enc.Encode(cov)
+		}
+	case pkgReport.FormatCobertura:
+		write = func(w io.Writer) error {
+			content, err := pkgReport.Cobertura(cov)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(w, content)
+
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported format: %q", *format)
+	}
+
+	return c.writeFile(*out, write)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+	pkgReport "github.com/willjunx/go-coverage-report/pkg/report"
+)
+
+const diffUsage = `
+	Usage: %s diff [OPTIONS] <OLD_COVERAGE_FILE> <NEW_COVERAGE_FILE>
+
+	Parse the OLD_COVERAGE_FILE and NEW_COVERAGE_FILE and compare the coverage of the
+	changed files. The result is printed to stdout as a simple Markdown table with emojis
+	indicating the coverage change per package.
+
+	Use the -output flag (repeatable) to write the same report in several formats at
+	once, e.g. -output markdown=comment.md -output json=report.json -output cobertura=-.
+	The html format renders the coverage tree of the changed files as a standalone page.
+
+	You can use the -root flag to add a prefix to all paths in the list of changed
+	files. This is useful to map the changed files (e.g., ["foo/my_file.go"] to their
+	coverage profile which uses the full package name to identify the files
+	(e.g., "github.com/username/example/foo/my_file.go"). Packages are identified
+	by their import path; their names are read from the source when available.
+
+	In a multi-module repository, both arguments may list several profiles separated
+	by commas, e.g. one per module, which are merged before comparing them. Use the
+	-workspace flag, or the modules of the configuration file, to report the coverage
+	of each module next to the combined one. A profile given as - is read from stdin,
+	and profiles compressed with gzip or zstd are decompressed.
+
+	ARGUMENTS:
+	  OLD_COVERAGE_FILE   The path to the old coverage file in the format produced by go test -coverprofile
+	  NEW_COVERAGE_FILE   The path to the new coverage file in the same format as OLD_COVERAGE_FILE
+
+	Every option can also be set in the configuration file or through the
+	environment variable shown next to it. Precedence is flag > env > file > default.
+
+	OPTIONS:
+`
+
+func (c *cli) runDiff(args []string) error {
+	fs := c.newFlagSet("diff", diffUsage)
+	opts := newOptions(fs)
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	outs, err := parseOutputs(conf)
+	if err != nil {
+		return err
+	}
+
+	result, err := compare(conf, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	if result.Empty() {
+		log.Println("Skipping report since there are no changed files")
+		return nil
+	}
+
+	for _, out := range outs {
+		if err := c.writeOutput(result, out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compare compares the coverage of the files changed between both
+// profiles.
+func compare(conf config.Config, oldCovPath, newCovPath string) (*pkgReport.Result, error) {
+	if err := checkStdin(oldCovPath, newCovPath); err != nil {
+		return nil, err
+	}
+
+	oldCov, err := parseCoverage(conf, oldCovPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse old coverage: %w", err)
+	}
+
+	newCov, err := parseCoverage(conf, newCovPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new coverage: %w", err)
+	}
+
+	logSourceNotFound(conf)
+
+	var known map[string]string
+
+	if conf.Renames.File != "" {
+		if known, err = pkgReport.ParseRenames(conf.Renames.File, conf.RootPackage); err != nil {
+			return nil, fmt.Errorf("failed to parse renames: %w", err)
+		}
+	}
+
+	return pkgReport.Compare(context.Background(), oldCov, newCov, pkgReport.Options{
+		Config:   &conf,
+		Renames:  known,
+		Packages: coverage.NewPackages(pkgReport.Sources(&conf)...),
+	})
+}
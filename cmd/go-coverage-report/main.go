@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -14,124 +16,268 @@ import (
 	pkgReport "github.com/willjunx/go-coverage-report/pkg/report"
 )
 
-var usage = strings.TrimSpace(fmt.Sprintf(`
-	Usage: %s [OPTIONS] <OLD_COVERAGE_FILE> <NEW_COVERAGE_FILE>
-	
-	Parse the OLD_COVERAGE_FILE and NEW_COVERAGE_FILE and compare the coverage of the
-	changed files. The result is printed to stdout as a simple Markdown table with emojis 
-	indicating the coverage change per package.
-	
-	You can use the -root flag to add a prefix to all paths in the list of changed
-	files. This is useful to map the changed files (e.g., ["foo/my_file.go"] to their
-	coverage profile which uses the full package name to identify the files
-	(e.g., "github.com/username/example/foo/my_file.go"). Note that currently,
-	packages with a different name than their directory are not supported.
-	
-	ARGUMENTS:
-	  OLD_COVERAGE_FILE   The path to the old coverage file in the format produced by go test -coverprofile
-	  NEW_COVERAGE_FILE   The path to the new coverage file in the same format as OLD_COVERAGE_FILE
-	
-	OPTIONS:
-`, filepath.Base(os.Args[0])))
+const usage = `
+	Usage: %s <COMMAND> [OPTIONS] [ARGUMENTS]
+	       %[1]s [OPTIONS] <OLD_COVERAGE_FILE> <NEW_COVERAGE_FILE>
+
+	Compare, summarize and check coverage profiles produced by go test -coverprofile.
+	Without a command, the two coverage files are compared like with the diff command.
+
+	COMMANDS:
+%s
+	Run '%[1]s help <COMMAND>' to show the options of a command.
+`
 
 type options struct {
-	root       string
-	trim       string
-	format     string
 	configPath string
+	// values holds the flags given for each config.Option, keyed by name.
+	values map[string][]string
+}
+
+// cli holds the output streams of a run of the command line.
+type cli struct {
+	stdout, stderr io.Writer
+}
+
+// command is a subcommand of the CLI, running with the arguments following
+// its name.
+type command struct {
+	name    string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+// errNoCommand is returned when the command line is run without arguments.
+var errNoCommand = errors.New("no command given")
+
+// commands lists the subcommands, the first one running without a command
+// name. It is set by init as the help command refers to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"diff", "compare the coverage of the changed files between two profiles (default)", (*cli).runDiff},
+		{"summary", "report the coverage of a single profile against the thresholds", (*cli).runSummary},
+		{"check", "check the thresholds without rendering a report", (*cli).runCheck},
+		{"merge", "merge several profiles into one", (*cli).runMerge},
+		{"convert", "convert a profile to another format", (*cli).runConvert},
+		{"normalize", "rewrite a profile in a canonical form", (*cli).runNormalize},
+		{"config", "validate configuration files", (*cli).runConfig},
+		{"help", "show the help of a command", (*cli).runHelp},
+	}
 }
 
 func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil && !errors.Is(err, flag.ErrHelp) {
+		log.Fatalln("ERROR:", err)
+	}
+}
+
+// run runs the command line with the given arguments, writing the reports
+// and profiles to stdout, and the usage and logs to stderr. It returns
+// flag.ErrHelp when the help of a command was requested.
+func run(args []string, stdout, stderr io.Writer) error {
 	log.SetFlags(0)
+	log.SetOutput(stderr)
+
+	c := &cli{stdout: stdout, stderr: stderr}
+
+	if len(args) == 0 {
+		c.printUsage()
+		return errNoCommand
+	}
+
+	if isHelpFlag(args[0]) {
+		c.printUsage()
+		return nil
+	}
+
+	// The former CLI only took the two coverage files, which is kept as an
+	// alias of the diff command.
+	cmd := commands[0]
+	if found, ok := lookupCommand(args[0]); ok {
+		cmd, args = found, args[1:]
+	}
+
+	return cmd.run(c, args)
+}
 
-	flag.Usage = func() {
-		_, err := fmt.Fprintln(os.Stderr, usage)
-		if err != nil {
-			panic(err)
+func lookupCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
 		}
+	}
 
-		flag.PrintDefaults()
+	return command{}, false
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+func (c *cli) printUsage() {
+	var list strings.Builder
+
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(&list, "\t  %-10s %s\n", cmd.name, cmd.summary)
 	}
 
-	flag.String("root", "", "The import path of the tested repository to add as prefix to all paths of the changed files")
-	flag.String("trim", "", "trim a prefix in the \"Impacted Packages\" column of the markdown report")
-	flag.String("format", "markdown", "output format (currently only 'markdown' is supported)")
-	flag.String("config", "", "path to the configuration file (.testcoverage.yaml), which defines test coverage settings and thresholds.")
+	_, _ = fmt.Fprintln(c.stderr, strings.TrimSpace(fmt.Sprintf(usage, filepath.Base(os.Args[0]), list.String())))
+}
 
-	err := run(programArgs())
-	if err != nil {
-		log.Fatalln("ERROR:", err)
+// runHelp shows the help of the given command, or the list of commands.
+func (c *cli) runHelp(args []string) error {
+	if len(args) == 0 {
+		c.printUsage()
+		return nil
+	}
+
+	cmd, ok := lookupCommand(args[0])
+	if !ok || cmd.name == "help" {
+		return fmt.Errorf("unknown command %q", args[0])
 	}
+
+	// The flag sets of the commands print their usage on -h.
+	if err := cmd.run(c, []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+
+	return nil
 }
 
-func programArgs() (oldCov, newCov string, opts options) {
-	flag.Parse()
+// newFlagSet returns the flag set of a command, printing its usage to stderr
+// on errors and -h.
+func (c *cli) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), strings.TrimSpace(fmt.Sprintf(usage, filepath.Base(os.Args[0]))))
+		fs.PrintDefaults()
+	}
 
-	args := flag.Args()
-	if len(args) != 2 {
-		if len(args) > 0 {
-			log.Printf("ERROR: Expected exactly 2 arguments but got %d\n\n", len(args))
-		}
+	return fs
+}
 
-		flag.Usage()
-		os.Exit(1)
+// parseArgs parses the flags of a command and checks the number of its
+// positional arguments, between minArgs and maxArgs (-1 for no limit).
+func parseArgs(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
 	}
 
-	opts = options{
-		root:       flag.Lookup("root").Value.String(),
-		trim:       flag.Lookup("trim").Value.String(),
-		format:     flag.Lookup("format").Value.String(),
-		configPath: flag.Lookup("config").Value.String(),
+	if n := fs.NArg(); n < minArgs || maxArgs >= 0 && n > maxArgs {
+		fs.Usage()
+		return nil, fmt.Errorf("unexpected number of arguments: %d", n)
 	}
 
-	return args[0], args[1], opts
+	return fs.Args(), nil
 }
 
-func run(oldCovPath, newCovPath string, opts options) error {
-	conf := config.Default
-	if opts.configPath != "" {
-		if err := config.FromFile(&conf, opts.configPath); err != nil {
-			return fmt.Errorf("failed to parse config: %w", err)
+// newOptions defines the -config flag and the flags of the config options on
+// fs.
+func newOptions(fs *flag.FlagSet) options {
+	opts := options{values: make(map[string][]string)}
+
+	fs.StringVar(&opts.configPath, "config", "", "path to the configuration file (.testcoverage.yaml), which defines test coverage settings and thresholds. "+
+		"Defaults to $"+configEnv+" or the file discovered from the working directory up to the module root.")
+
+	for _, opt := range config.Options {
+		usage := fmt.Sprintf("%s ($%s)", opt.Usage, opt.Env())
+		set := func(value string) error {
+			opts.values[opt.Name] = append(opts.values[opt.Name], value)
+			return nil
+		}
+
+		if opt.Bool {
+			fs.BoolFunc(opt.Name, usage, set)
+		} else {
+			fs.Func(opt.Name, usage, set)
 		}
 	}
 
-	oldCov, err := coverage.NewCoverageFromFile(oldCovPath)
+	return opts
+}
+
+// parseCoverage parses and merges the comma separated list of profiles.
+func parseCoverage(conf config.Config, arg string) (*coverage.Coverage, error) {
+	return coverage.NewCoverageFromFilesWithOptions(coverage.ParseOptions{Lenient: conf.Lenient}, profilePaths(arg)...)
+}
+
+// loadCoverage parses the profiles, logging the skipped lines, and drops the
+// code excluded by the config.
+func loadCoverage(conf config.Config, arg string) (*coverage.Coverage, error) {
+	cov, err := parseCoverage(conf, arg)
 	if err != nil {
-		return fmt.Errorf("failed to parse old coverage: %w", err)
+		return nil, fmt.Errorf("failed to parse coverage: %w", err)
 	}
 
-	newCov, err := coverage.NewCoverageFromFile(newCovPath)
+	logDiagnostics(cov)
+
+	cov, _ = excludeSource(conf, cov, cov)
+
+	exclude, err := conf.Exclude.Matcher(conf.RootPackage)
 	if err != nil {
-		return fmt.Errorf("failed to parse new coverage: %w", err)
+		return nil, err
 	}
 
-	changedFiles := pkgReport.GetChangedFiles(oldCov, newCov, conf.Exclude.Paths)
-	if len(changedFiles) == 0 {
-		log.Println("Skipping report since there are no changed files")
-		return nil
+	return pkgReport.Exclude(cov, exclude), nil
+}
+
+// logDiagnostics logs the lines skipped by lenient parsing.
+func logDiagnostics(cov *coverage.Coverage) {
+	for _, d := range cov.Diagnostics {
+		log.Printf("WARNING: skipped %s", d)
 	}
+}
 
-	conf.RootPackage = opts.root
+// checkStdin rejects the arguments reading more than one profile from stdin.
+func checkStdin(args ...string) error {
+	var n int
 
-	report := pkgReport.New(&conf, oldCov, newCov, changedFiles)
-	if opts.trim != "" {
-		report.TrimPrefix(opts.trim)
+	for _, arg := range args {
+		for _, p := range profilePaths(arg) {
+			if p == coverage.StdinPath {
+				n++
+			}
+		}
 	}
 
-	switch strings.ToLower(opts.format) {
-	case "markdown":
-		_, err = fmt.Fprintln(os.Stdout, report.Markdown())
-		if err != nil {
-			panic(err)
-		}
-	case "json":
-		_, err = fmt.Fprintln(os.Stdout, report.JSON())
-		if err != nil {
-			panic(err)
-		}
-	default:
-		return fmt.Errorf("unsupported format: %q", opts.format)
+	if n > 1 {
+		return errors.New("only one profile can be read from stdin")
 	}
 
 	return nil
 }
+
+// profilePaths splits a comma separated list of profiles.
+func profilePaths(arg string) []string {
+	var res []string
+
+	for _, p := range strings.Split(arg, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+// excludeSource drops the generated files and the code annotated with
+// //coverage:ignore, when the source of the modules is available.
+func excludeSource(conf config.Config, oldCov, newCov *coverage.Coverage) (*coverage.Coverage, *coverage.Coverage) {
+	oldCov, newCov, err := pkgReport.ExcludeSource(&conf, oldCov, newCov)
+	if err != nil {
+		log.Printf("Skipping source based exclusions: %v", err)
+	}
+
+	return oldCov, newCov
+}
+
+// logSourceNotFound logs the source based exclusions skipped by
+// pkgReport.Compare when the source of the modules is not found.
+func logSourceNotFound(conf config.Config) {
+	if (conf.Exclude.Generated || conf.Exclude.Directives) && len(pkgReport.Sources(&conf)) == 0 {
+		log.Printf("Skipping source based exclusions: %v", coverage.ErrModuleNotFound)
+	}
+}
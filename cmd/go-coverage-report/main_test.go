@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+)
+
+const (
+	oldProfile = "../../pkg/report/testdata/01-old-coverage.txt"
+	newProfile = "../../pkg/report/testdata/01-new-coverage.txt"
+)
+
+var _ = Describe("Command line", func() {
+	var stdout, stderr *bytes.Buffer
+
+	BeforeEach(func() {
+		stdout, stderr = new(bytes.Buffer), new(bytes.Buffer)
+	})
+
+	Context("run", func() {
+		It("Should compare two profiles without a command like diff", func() {
+			Expect(run([]string{oldProfile, newProfile}, stdout, stderr)).To(Succeed())
+			alias := stdout.String()
+			Expect(alias).To(ContainSubstring("github.com/username/prioqueue"))
+
+			stdout.Reset()
+			Expect(run([]string{"diff", oldProfile, newProfile}, stdout, stderr)).To(Succeed())
+			Expect(stdout.String()).To(Equal(alias))
+		})
+
+		It("Should print the usage without arguments", func() {
+			Expect(run(nil, stdout, stderr)).To(MatchError(errNoCommand))
+			Expect(stderr.String()).To(ContainSubstring("COMMANDS:"))
+		})
+
+		It("Should print the help of a command", func() {
+			Expect(run([]string{"help", "normalize"}, stdout, stderr)).To(Succeed())
+			Expect(stderr.String()).To(ContainSubstring("normalize [OPTIONS] <COVERAGE_FILE>"))
+
+			Expect(run([]string{"summary", "-h"}, stdout, stderr)).To(MatchError(flag.ErrHelp))
+			Expect(run([]string{"help", "unknown"}, stdout, stderr)).To(MatchError(`unknown command "unknown"`))
+		})
+
+		DescribeTable("Should reject an unexpected number of arguments",
+			func(args ...string) {
+				Expect(run(args, stdout, stderr)).To(MatchError(HavePrefix("unexpected number of arguments")))
+				Expect(stderr.String()).To(ContainSubstring("Usage:"))
+				Expect(stdout.String()).To(BeEmpty())
+			},
+			Entry("diff with one profile", "diff", newProfile),
+			Entry("the diff alias with three profiles", oldProfile, newProfile, newProfile),
+			Entry("summary without profile", "summary"),
+			Entry("check with three profiles", "check", oldProfile, newProfile, newProfile),
+			Entry("merge without profile", "merge"),
+			Entry("convert with two profiles", "convert", oldProfile, newProfile),
+			Entry("normalize with two profiles", "normalize", oldProfile, newProfile),
+			Entry("config without subcommand", "config"),
+		)
+
+		It("Should fail the check when a threshold is not met", func() {
+			Expect(run([]string{"check", "-threshold-total", "95", oldProfile, newProfile}, stdout, stderr)).
+				To(MatchError("coverage check failed: total coverage"))
+			Expect(run([]string{"check", "-threshold-total", "95", newProfile}, stdout, stderr)).
+				To(MatchError("coverage check failed: total coverage"))
+
+			Expect(run([]string{"check", "-threshold-total", "90", newProfile}, stdout, stderr)).To(Succeed())
+			Expect(stderr.String()).To(ContainSubstring("Coverage check passed"))
+			Expect(stdout.String()).To(BeEmpty())
+		})
+
+		It("Should write the normalized profile to the -o file", func() {
+			out := filepath.Join(GinkgoT().TempDir(), "normalized", "coverage.txt")
+
+			Expect(run([]string{"normalize", "-o", out, newProfile + "," + newProfile}, stdout, stderr)).To(Succeed())
+			Expect(stdout.String()).To(BeEmpty())
+
+			normalized, err := os.ReadFile(out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(normalized)).To(HavePrefix("mode: count\n"))
+			Expect(string(normalized)).To(ContainSubstring("github.com/username/prioqueue/max_heap.go:38.36,40.14 2 6\n"))
+		})
+	})
+
+	Context("normalize", func() {
+		It("Should merge the overlapping blocks", func() {
+			var (
+				dir = GinkgoT().TempDir()
+				a   = filepath.Join(dir, "a.txt")
+				b   = filepath.Join(dir, "b.txt")
+			)
+
+			Expect(os.WriteFile(a, []byte("mode: count\n"+
+				"example.com/app/main.go:3.10,5.2 2 1\n"+
+				"example.com/app/main.go:1.1,2.2 1 0\n"), 0o600)).To(Succeed())
+			Expect(os.WriteFile(b, []byte("mode: count\n"+
+				"example.com/app/main.go:3.10,5.2 2 4\n"+
+				"example.com/app/main.go:4.1,6.2 2 1\n"), 0o600)).To(Succeed())
+
+			Expect(run([]string{"normalize", "-trim", "example.com", a + "," + b}, stdout, stderr)).To(Succeed())
+			Expect(stdout.String()).To(Equal("mode: count\n" +
+				"app/main.go:1.1,2.2 1 0\n" +
+				"app/main.go:3.10,6.2 2 6\n"))
+		})
+	})
+
+	Context("loadConfig", func() {
+		It("Should validate the config once the flags are applied", func() {
+			path := filepath.Join(GinkgoT().TempDir(), ".testcoverage.yaml")
+			Expect(os.WriteFile(path, []byte("threshold:\n  file: 120\n"), 0o600)).To(Succeed())
+
+			conf, err := loadConfig(options{configPath: path, values: map[string][]string{"threshold-file": {"50"}}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conf.Threshold.File).To(Equal(50))
+
+			_, err = loadConfig(options{configPath: path})
+			Expect(err).To(MatchError(ContainSubstring("threshold.file: threshold must be in range [0 - 100]")))
+
+			Expect(os.WriteFile(path, []byte("threshold:\n  file: 50\n"), 0o600)).To(Succeed())
+
+			_, err = loadConfig(options{configPath: path, values: map[string][]string{"threshold-file": {"150"}}})
+			Expect(err).To(MatchError(ContainSubstring("threshold.file: threshold must be in range [0 - 100]")))
+		})
+	})
+
+	Context("profilePaths", func() {
+		It("Should split the comma separated profiles", func() {
+			Expect(profilePaths(" a.txt, ,b.txt,")).To(Equal([]string{"a.txt", "b.txt"}))
+			Expect(profilePaths("")).To(BeEmpty())
+		})
+	})
+
+	Context("checkStdin", func() {
+		It("Should only read one profile from stdin", func() {
+			Expect(checkStdin("-", "a.txt,b.txt")).To(Succeed())
+			Expect(checkStdin("a.txt,-", "-")).To(MatchError("only one profile can be read from stdin"))
+		})
+	})
+
+	Context("parseOutputs", func() {
+		It("Should default to the format written to stdout", func() {
+			outs, err := parseOutputs(config.Config{Format: "json"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outs).To(Equal([]output{{format: "json", path: stdoutPath}}))
+		})
+
+		It("Should parse the outputs", func() {
+			outs, err := parseOutputs(config.Config{Format: "json", Outputs: []string{"Markdown=comment.md", "cobertura"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outs).To(Equal([]output{{format: "markdown", path: "comment.md"}, {format: "cobertura", path: stdoutPath}}))
+		})
+
+		It("Should reject invalid outputs", func() {
+			_, err := parseOutputs(config.Config{Outputs: []string{"xml=report.xml"}})
+			Expect(err).To(MatchError(`unsupported format: "xml"`))
+
+			_, err = parseOutputs(config.Config{Outputs: []string{"json="}})
+			Expect(err).To(MatchError(`invalid output "json=", expected format=path`))
+		})
+	})
+
+	Context("workspaceModules", func() {
+		It("Should add the modules of the workspace", func() {
+			total := 80
+			conf := config.Default
+			conf.Source = "../../pkg/coverage/testdata/workspace/tools"
+			conf.Modules = []config.Module{{Path: "example.com/mono/api", Threshold: config.ModuleThreshold{Total: &total}}}
+
+			Expect(workspaceModules(&conf)).To(Succeed())
+			Expect(conf.Modules).To(Equal([]config.Module{
+				{Path: "example.com/mono/api", Dir: "../api", Threshold: config.ModuleThreshold{Total: &total}},
+				{Path: "example.com/mono/tools/lint", Dir: "lint"},
+			}))
+		})
+
+		It("Should fail without workspace", func() {
+			conf := config.Default
+			conf.Source = "/"
+
+			Expect(workspaceModules(&conf)).To(MatchError(HavePrefix("failed to read workspace")))
+		})
+	})
+})
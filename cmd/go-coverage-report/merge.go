@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+const mergeUsage = `
+	Usage: %s merge [OPTIONS] <COVERAGE_FILE>...
+
+	Merge the coverage files, e.g. the profiles of the modules of a workspace or of
+	several test runs, into a single profile. The blocks reported by several files
+	are merged, their counts added up, or combined for the set mode. The profile is
+	written to stdout unless -o is given.
+
+	OPTIONS:
+`
+
+func (c *cli) runMerge(args []string) error {
+	fs := c.newFlagSet("merge", mergeUsage)
+	out := fs.String("o", stdoutPath, "`path` of the merged profile, - for stdout")
+	lenient := fs.Bool("lenient", false, "skip the invalid lines of the coverage files instead of failing")
+	args, err := parseArgs(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, arg := range args {
+		paths = append(paths, profilePaths(arg)...)
+	}
+
+	if err := checkStdin(paths...); err != nil {
+		return err
+	}
+
+	cov, err := coverage.NewCoverageFromFilesWithOptions(coverage.ParseOptions{Lenient: *lenient}, paths...)
+	if err != nil {
+		return err
+	}
+
+	logDiagnostics(cov)
+
+	return c.writeFile(*out, cov.WriteProfile)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// workspaceModules adds the modules of the go.work file of the source
+// directory to the configured ones. Configured modules keep their thresholds
+// and get their directory from the workspace when unset.
+func workspaceModules(conf *config.Config) error {
+	ws, err := coverage.FindWorkspace(conf.Source)
+	if err != nil {
+		return fmt.Errorf("failed to read workspace: %w", err)
+	}
+
+	source, err := filepath.Abs(conf.Source)
+	if err != nil {
+		return err
+	}
+
+	configured := make(map[string]int, len(conf.Modules))
+	for i, m := range conf.Modules {
+		configured[m.Path] = i
+	}
+
+	for _, m := range ws.Modules {
+		dir, err := filepath.Rel(source, filepath.Join(ws.Dir, filepath.FromSlash(m.Dir)))
+		if err != nil {
+			return err
+		}
+
+		i, ok := configured[m.Path]
+		if !ok {
+			conf.Modules = append(conf.Modules, config.Module{Path: m.Path, Dir: filepath.ToSlash(dir)})
+			continue
+		}
+
+		if conf.Modules[i].Dir == "" {
+			conf.Modules[i].Dir = filepath.ToSlash(dir)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+const normalizeUsage = `
+	Usage: %s normalize [OPTIONS] <COVERAGE_FILE>
+
+	Rewrite the COVERAGE_FILE in a canonical form, e.g. to store a baseline in git:
+	the blocks reported several times are merged, the excluded files and code are
+	dropped, the paths are trimmed and the files and blocks are sorted. Like for a
+	report, COVERAGE_FILE may list several profiles separated by commas, which are
+	merged. The profile is written to stdout unless -o is given.
+
+	Blocks partially overlapping each other, e.g. from test binaries built from
+	different versions of a file, are merged into a block spanning them, holding the
+	most statements of them and their merged counts.
+
+	OPTIONS:
+`
+
+func (c *cli) runNormalize(args []string) error {
+	fs := c.newFlagSet("normalize", normalizeUsage)
+	out := fs.String("o", stdoutPath, "`path` of the normalized profile, - for stdout")
+	opts := newOptions(fs)
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	cov, err := loadCoverage(conf, args[0])
+	if err != nil {
+		return err
+	}
+
+	cov = cov.MergeOverlaps()
+
+	if conf.Trim != "" {
+		cov = cov.TrimPrefix(conf.Trim)
+	}
+
+	return c.writeFile(*out, cov.WriteProfile)
+}
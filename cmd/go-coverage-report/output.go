@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	pkgReport "github.com/willjunx/go-coverage-report/pkg/report"
+)
+
+const stdoutPath = "-"
+
+type output struct {
+	format string
+	path   string
+}
+
+// parseOutputs returns the configured outputs, falling back to the single
+// format written to stdout.
+func parseOutputs(conf config.Config) ([]output, error) {
+	specs := conf.Outputs
+	if len(specs) == 0 {
+		specs = []string{conf.Format}
+	}
+
+	outs := make([]output, 0, len(specs))
+
+	for _, spec := range specs {
+		out, err := parseOutput(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		outs = append(outs, out)
+	}
+
+	return outs, nil
+}
+
+func parseOutput(spec string) (output, error) {
+	format, path, found := strings.Cut(spec, "=")
+	if !found {
+		path = stdoutPath
+	}
+
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" || path == "" {
+		return output{}, fmt.Errorf("invalid output %q, expected format=path", spec)
+	}
+
+	if !slices.Contains(pkgReport.Formats, format) {
+		return output{}, fmt.Errorf("unsupported format: %q", format)
+	}
+
+	return output{format: format, path: path}, nil
+}
+
+// renderer is a report rendered in the supported formats.
+type renderer interface {
+	Write(w io.Writer, format string) error
+}
+
+func (c *cli) writeOutput(report renderer, out output) error {
+	var b strings.Builder
+	if err := report.Write(&b, out.format); err != nil {
+		return err
+	}
+
+	content := strings.TrimSuffix(b.String(), "\n")
+
+	if out.path == stdoutPath {
+		_, err := fmt.Fprintln(c.stdout, content)
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Clean(out.path), []byte(content+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s output: %w", out.format, err)
+	}
+
+	return nil
+}
+
+// writeFile writes the content written by write to the file at path, or to
+// stdout.
+func (c *cli) writeFile(path string, write func(w io.Writer) error) error {
+	if path == stdoutPath {
+		return write(c.stdout)
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Clean(path), buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
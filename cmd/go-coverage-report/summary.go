@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+	pkgReport "github.com/willjunx/go-coverage-report/pkg/report"
+)
+
+const summaryUsage = `
+	Usage: %s summary [OPTIONS] <COVERAGE_FILE>
+
+	Report the coverage of each package and file of the COVERAGE_FILE and check it
+	against the thresholds, without a baseline to compare with, e.g. for the first
+	pull request of a repository. The outputs, exclusions and thresholds are
+	configured like for the diff command.
+
+	OPTIONS:
+`
+
+func (c *cli) runSummary(args []string) error {
+	fs := c.newFlagSet("summary", summaryUsage)
+	opts := newOptions(fs)
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	outs, err := parseOutputs(conf)
+	if err != nil {
+		return err
+	}
+
+	cov, err := loadCoverage(conf, args[0])
+	if err != nil {
+		return err
+	}
+
+	summary := pkgReport.NewSummary(&conf, cov)
+	summary.PackageNames = coverage.NewPackages(pkgReport.Sources(&conf)...).Names(summary.Packages)
+
+	for _, out := range outs {
+		if err := c.writeOutput(summary, out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ module github.com/willjunx/go-coverage-report
 go 1.23.4
 
 require (
+	github.com/bmatcuk/doublestar/v4 v4.7.1
+	github.com/klauspost/compress v1.17.11
 	github.com/onsi/ginkgo/v2 v2.22.1
 	github.com/onsi/gomega v1.36.2
 	gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	PlatformGitHub = "github"
+	PlatformGitLab = "gitlab"
+	PlatformNone   = "none"
+)
+
+// commentLimits holds the size limit of the comments of each platform, 0
+// being unlimited. GitHub allows 65,536 characters, the margin leaves room
+// for the tag added to the comment.
+var commentLimits = map[string]int{
+	PlatformGitHub: 65000,
+	PlatformGitLab: 1000000,
+	PlatformNone:   0,
+}
+
+// Comment configures the size of the markdown report, posted as a comment on
+// the pull request. Larger reports are shortened to fit, keeping the
+// summary, the failed checks and the biggest regressions.
+type Comment struct {
+	// Platform selects the size limit of its comments.
+	Platform string `yaml:"platform"`
+	// Limit overrides the size limit of the platform, in bytes; 0 keeps the
+	// one of the platform.
+	Limit int `yaml:"limit"`
+}
+
+// MaxSize returns the size limit of the markdown report in bytes, 0 being
+// unlimited.
+func (c Comment) MaxSize() int {
+	if c.Limit > 0 {
+		return c.Limit
+	}
+
+	return commentLimits[c.Platform]
+}
+
+func (c Comment) validate() error {
+	var errs []error
+
+	if _, ok := commentLimits[c.Platform]; !ok && c.Platform != "" {
+		errs = append(errs, &FieldError{Field: "comment.platform", Err: fmt.Errorf("%q %w", c.Platform, ErrUnknownPlatform)})
+	}
+
+	if c.Limit < 0 {
+		errs = append(errs, &FieldError{Field: "comment.limit", Err: ErrNegative})
+	}
+
+	return errors.Join(errs...)
+}
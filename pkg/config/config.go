@@ -1,36 +1,170 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 var Default = Config{
 	RootPackage: "",
+	Format:      "markdown",
+	Source:      ".",
 	Threshold: Threshold{
 		File:    0,
 		Package: 0,
 		Total:   0,
 	},
-	Exclude: Exclude{Paths: nil},
+	Exclude: Exclude{
+		Paths:      nil,
+		Generated:  false,
+		Directives: false,
+	},
+	Symbols: Symbols{Preset: PresetEmoji},
+	Tree:    Tree{Enabled: false, Depth: 3},
+	Renames: Renames{File: "", Similarity: 0},
+	Comment: Comment{Platform: PlatformGitHub},
 }
 
+// FromFile reads the configuration file into cfg. Unknown fields are
+// rejected, while the values are left to Validate, once the other sources
+// have been applied.
 func FromFile(cfg *Config, filename string) error {
-	source, err := os.ReadFile(filepath.Clean(filename))
+	_, err := fromFile(cfg, filename)
+	return err
+}
+
+// ValidateFile reads the configuration file into cfg and validates the
+// result, reporting the errors with their position in the file.
+func ValidateFile(cfg *Config, filename string) error {
+	root, err := fromFile(cfg, filename)
 	if err != nil {
-		return fmt.Errorf("failed reading file: %w", err)
+		return err
 	}
 
-	err = yaml.Unmarshal(source, cfg)
+	err = cfg.Validate()
+	locate(root, err)
+
+	return err
+}
+
+// fromFile reads the configuration file into cfg, returning its YAML tree.
+func fromFile(cfg *Config, filename string) (*yaml.Node, error) {
+	source, err := os.ReadFile(filepath.Clean(filename))
 	if err != nil {
-		return fmt.Errorf("failed parsing config file: %w", err)
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(source))
+	dec.KnownFields(true)
+
+	if err = dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed parsing config file: %w", err)
 	}
 
+	var root yaml.Node
+	if err = yaml.Unmarshal(source, &root); err != nil {
+		return nil, fmt.Errorf("failed parsing config file: %w", err)
+	}
+
+	return &root, nil
+}
+
+// Validate checks the configuration once all sources have been applied.
+func (c *Config) Validate() error {
 	return errors.Join(
-		cfg.Threshold.validate(),
+		c.Threshold.validate("threshold"),
+		c.Exclude.validate(),
+		c.Symbols.validate(),
+		c.Tree.validate(),
+		c.Renames.validate(),
+		c.Display.validate(),
+		c.Comment.validate(),
+		c.Risk.validate(),
+		validateModules(c.Modules),
 	)
 }
+
+// locate sets the position of every FieldError in err from the YAML tree.
+func locate(root *yaml.Node, err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			locate(root, e)
+		}
+
+		return
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		return
+	}
+
+	// a missing field is located at its closest parent
+	for field := fieldErr.Field; field != ""; field, _ = cutLast(field) {
+		if node := lookup(root, field); node != nil {
+			fieldErr.Line, fieldErr.Column = node.Line, node.Column
+			return
+		}
+	}
+}
+
+// lookup returns the node of a field path such as "exclude.paths[1]".
+func lookup(node *yaml.Node, field string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, part := range strings.Split(field, ".") {
+		name, index, hasIndex := strings.Cut(strings.TrimSuffix(part, "]"), "[")
+
+		node = mappingValue(node, name)
+		if node == nil {
+			return nil
+		}
+
+		if !hasIndex {
+			continue
+		}
+
+		i, err := strconv.Atoi(index)
+		if err != nil || node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+			return nil
+		}
+
+		node = node.Content[i]
+	}
+
+	return node
+}
+
+// cutLast removes the last element of a field path, either a key or an index.
+func cutLast(field string) (string, bool) {
+	i := strings.LastIndexAny(field, ".[")
+	if i < 0 {
+		return "", false
+	}
+
+	return field[:i], true
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package config_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+)
+
+var _ = Describe("Config", func() {
+	Context("FromFile", func() {
+		It("Should load the example", func() {
+			cfg := config.Default
+			Expect(config.FromFile(&cfg, "../../.testcoverage.example.yaml")).To(Succeed())
+			Expect(cfg.Threshold).To(Equal(config.Threshold{File: 70, Package: 80, Directory: 75, Total: 95, Branch: 60}))
+			Expect(cfg.Tree).To(Equal(config.Tree{Enabled: true, Depth: 3}))
+			Expect(cfg.Modules).To(HaveLen(1))
+		})
+
+		It("Should only exclude code based on the source when enabled", func() {
+			Expect(config.Default.Exclude.Generated).To(BeFalse())
+			Expect(config.Default.Exclude.Directives).To(BeFalse())
+
+			cfg := config.Default
+			Expect(config.FromFile(&cfg, "../../.testcoverage.example.yaml")).To(Succeed())
+			Expect(cfg.Exclude.Generated).To(BeTrue())
+			Expect(cfg.Exclude.Directives).To(BeTrue())
+		})
+
+		When("a field is unknown", func() {
+			It("Should return an error", func() {
+				cfg := config.Default
+				err := config.FromFile(&cfg, "testdata/unknown-field.yaml")
+				Expect(err).To(MatchError(ContainSubstring("line 1: field threshhold not found")))
+			})
+		})
+
+		It("Should leave the validation of the values to Validate", func() {
+			cfg := config.Default
+			Expect(config.FromFile(&cfg, "testdata/invalid.yaml")).To(Succeed())
+			Expect(cfg.Threshold.File).To(Equal(120))
+			Expect(cfg.Validate()).NotTo(Succeed())
+		})
+
+		When("fields are invalid", func() {
+			It("Should return every error with its position", func() {
+				cfg := config.Default
+				err := config.ValidateFile(&cfg, "testdata/invalid.yaml")
+				Expect(err).To(HaveOccurred())
+				Expect(errors.Is(err, config.ErrThresholdNotInRange)).To(BeTrue())
+				Expect(errors.Is(err, config.ErrUnknownPreset)).To(BeTrue())
+
+				Expect(err.Error()).To(Equal(`line 2, column 9: threshold.file: threshold must be in range [0 - 100]
+line 6, column 7: exclude.paths[1]: error parsing regexp: missing closing ): ` + "`(unclosed`" + `
+line 8, column 11: symbols.preset: "fancy" is not a known preset`))
+			})
+		})
+	})
+
+	Context("Modules", func() {
+		It("Should apply the thresholds of the module of a file", func() {
+			cfg := config.Default
+			Expect(config.FromFile(&cfg, "testdata/modules.yaml")).To(Succeed())
+
+			m, ok := cfg.ModuleOf("example.com/mono/api/v1/handler.go")
+			Expect(ok).To(BeTrue())
+			Expect(m.Path).To(Equal("example.com/mono/api"))
+
+			Expect(cfg.ThresholdOf("example.com/mono/api/v1/handler.go")).To(Equal(config.Threshold{File: 90, Total: 80}))
+			Expect(cfg.ThresholdOf("example.com/mono/cmd/main.go")).To(Equal(config.Threshold{File: 60, Total: 70}))
+			Expect(cfg.ThresholdOf("example.com/monolith/main.go")).To(Equal(config.Threshold{File: 60, Total: 80}))
+			Expect(cfg.ThresholdOf("example.com/mono/tools/lint.go")).To(Equal(config.Threshold{File: 0, Total: 80}), "0 disables the check")
+		})
+
+		It("Should return every error with its position", func() {
+			cfg := config.Default
+			err := config.ValidateFile(&cfg, "testdata/invalid-modules.yaml")
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, config.ErrMissing)).To(BeTrue())
+			Expect(errors.Is(err, config.ErrDuplicate)).To(BeTrue())
+
+			Expect(err.Error()).To(Equal(`line 2, column 5: modules[0].path: is required
+line 5, column 14: modules[1].threshold.total: threshold must be in range [0 - 100]
+line 6, column 11: modules[2].path: is defined more than once`))
+		})
+	})
+
+	Context("Comment", func() {
+		It("Should use the limit of the platform", func() {
+			Expect(config.Default.Comment.MaxSize()).To(Equal(65000))
+			Expect(config.Comment{Platform: config.PlatformGitLab}.MaxSize()).To(Equal(1000000))
+			Expect(config.Comment{Platform: config.PlatformNone}.MaxSize()).To(BeZero())
+			Expect(config.Comment{Platform: config.PlatformNone, Limit: 1000}.MaxSize()).To(Equal(1000))
+		})
+
+		It("Should reject unknown platforms and negative limits", func() {
+			cfg := config.Default
+			cfg.Comment = config.Comment{Platform: "forge", Limit: -1}
+
+			err := cfg.Validate()
+			Expect(errors.Is(err, config.ErrUnknownPlatform)).To(BeTrue())
+			Expect(errors.Is(err, config.ErrNegative)).To(BeTrue())
+			Expect(err.Error()).To(Equal(`comment.platform: "forge" is not a known platform
+comment.limit: must not be negative`))
+		})
+	})
+})
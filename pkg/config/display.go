@@ -0,0 +1,228 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// Display configures the names of the files and packages shown by the
+// reports. The reports are computed on the full import paths, which are
+// only mapped to these names when rendered.
+type Display struct {
+	// Rewrite rules are applied in order to the names, once the prefix of
+	// Config.Trim is trimmed.
+	Rewrite []RewriteRule `yaml:"rewrite"`
+	Links   Links         `yaml:"links"`
+}
+
+// Links configures the links of the reports to the source of the files and
+// packages at the new commit, and to the full report. Templates may refer to
+// the {repo} and {sha} variables, and to the {path} of the file or package
+// relative to the repository root; the lines template also to the {line} and
+// {end} of a line range. Files and packages are not linked without template.
+type Links struct {
+	Repo string `yaml:"repo"`
+	SHA  string `yaml:"sha"`
+	// File links the files, e.g.
+	// https://github.com/{repo}/blob/{sha}/{path}.
+	File string `yaml:"file"`
+	// Package links the packages and directories, e.g.
+	// https://github.com/{repo}/tree/{sha}/{path}.
+	Package string `yaml:"package"`
+	// Lines links the line ranges of the files, e.g.
+	// https://github.com/{repo}/blob/{sha}/{path}#L{line}-L{end}. It also
+	// lists the uncovered lines of the changed files in the report.
+	Lines string `yaml:"lines"`
+	// Report links the full coverage report, e.g. an HTML report uploaded
+	// as an artifact of the CI run.
+	Report string `yaml:"report"`
+}
+
+var templateVariable = regexp.MustCompile(`\{(\w+)\}`)
+
+func (l Links) validate() error {
+	var errs []error
+
+	for _, t := range []struct {
+		field, template string
+		vars            []string
+	}{
+		{"file", l.File, []string{"repo", "sha", "path"}},
+		{"package", l.Package, []string{"repo", "sha", "path"}},
+		{"lines", l.Lines, []string{"repo", "sha", "path", "line", "end"}},
+		{"report", l.Report, []string{"repo", "sha"}},
+	} {
+		for _, m := range templateVariable.FindAllStringSubmatch(t.template, -1) {
+			if !contains(t.vars, m[1]) {
+				errs = append(errs, &FieldError{Field: "display.links." + t.field, Err: fmt.Errorf("%w %s", ErrUnknownVariable, m[0])})
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RewriteRule replaces the matches of a regular expression, the replacement
+// may refer to its submatches like regexp.Regexp.ReplaceAllString.
+type RewriteRule struct {
+	Pattern string `yaml:"pattern"`
+	Replace string `yaml:"replace"`
+}
+
+// ParseRewriteRule parses the `pattern=replacement` form of a rule, split at
+// the first "=".
+func ParseRewriteRule(s string) (RewriteRule, error) {
+	pattern, replace, ok := strings.Cut(s, "=")
+	if !ok {
+		return RewriteRule{}, fmt.Errorf("rewrite rule %q must be given as pattern=replacement", s)
+	}
+
+	return RewriteRule{Pattern: pattern, Replace: replace}, nil
+}
+
+// DisplayNames maps the import paths of files and packages to the names and
+// links shown by the reports. A nil DisplayNames shows the import paths
+// without links.
+type DisplayNames struct {
+	trim    string
+	rules   []compiledRewrite
+	links   Links
+	root    string
+	modules []Module
+}
+
+type compiledRewrite struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+// DisplayNames compiles the rewrite rules, trimming the prefix of Trim
+// first, and the link templates.
+func (c *Config) DisplayNames() (*DisplayNames, error) {
+	var (
+		names = &DisplayNames{
+			trim:    c.Trim,
+			rules:   make([]compiledRewrite, 0, len(c.Display.Rewrite)),
+			links:   c.Display.Links,
+			root:    c.RootPackage,
+			modules: c.Modules,
+		}
+		errs = []error{c.Display.Links.validate()}
+	)
+
+	for i, rule := range c.Display.Rewrite {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			errs = append(errs, &FieldError{Field: fmt.Sprintf("display.rewrite[%d].pattern", i), Err: err})
+			continue
+		}
+
+		names.rules = append(names.rules, compiledRewrite{re: re, replace: rule.Replace})
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func (d Display) validate() error {
+	_, err := (&Config{Display: d}).DisplayNames()
+	return err
+}
+
+// Name returns the name shown for the import path of a file or package.
+func (n *DisplayNames) Name(importPath string) string {
+	if n == nil {
+		return importPath
+	}
+
+	name := importPath
+
+	if n.trim != "" {
+		name = coverage.TrimPrefix(name, n.trim)
+	}
+
+	for _, r := range n.rules {
+		name = r.re.ReplaceAllString(name, r.replace)
+	}
+
+	return name
+}
+
+// FileURL returns the link of a file, or an empty string.
+func (n *DisplayNames) FileURL(importPath string) string {
+	return n.expand(n.templates().File, importPath)
+}
+
+// PackageURL returns the link of a package or directory, or an empty string.
+func (n *DisplayNames) PackageURL(importPath string) string {
+	return n.expand(n.templates().Package, importPath)
+}
+
+// LinesURL returns the link of a line range of a file, or an empty string.
+func (n *DisplayNames) LinesURL(importPath string, start, end int) string {
+	return n.expand(n.templates().Lines, importPath, "{line}", strconv.Itoa(start), "{end}", strconv.Itoa(end))
+}
+
+// HasLines tells whether the line ranges of the files are linked.
+func (n *DisplayNames) HasLines() bool {
+	return n.templates().Lines != ""
+}
+
+// ReportURL returns the link of the full report, or an empty string.
+func (n *DisplayNames) ReportURL() string {
+	return n.expand(n.templates().Report, "")
+}
+
+func (n *DisplayNames) templates() Links {
+	if n == nil {
+		return Links{}
+	}
+
+	return n.links
+}
+
+func (n *DisplayNames) expand(template, importPath string, vars ...string) string {
+	if template == "" {
+		return ""
+	}
+
+	vars = append(vars, "{repo}", n.links.Repo, "{sha}", n.links.SHA, "{path}", n.sourcePath(importPath))
+
+	return strings.NewReplacer(vars...).Replace(template)
+}
+
+// sourcePath returns the path of a file or package relative to the
+// repository root: its path in its module below the directory of the module,
+// or its path relative to the root package.
+func (n *DisplayNames) sourcePath(importPath string) string {
+	root, dir := n.root, ""
+
+	if m, ok := (&Config{Modules: n.modules}).ModuleOf(importPath); ok {
+		root, dir = m.Path, m.Dir
+	}
+
+	if root == "" || importPath != root && !strings.HasPrefix(importPath, root+"/") {
+		return importPath
+	}
+
+	return path.Join(dir, strings.TrimPrefix(strings.TrimPrefix(importPath, root), "/"))
+}
@@ -0,0 +1,104 @@
+package config_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+)
+
+var _ = Describe("Display", func() {
+	DescribeTable("Name",
+		func(trim string, rules []config.RewriteRule, importPath, expected string) {
+			cfg := config.Default
+			cfg.Trim = trim
+			cfg.Display.Rewrite = rules
+
+			names, err := cfg.DisplayNames()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names.Name(importPath)).To(Equal(expected))
+		},
+		Entry("without rules", "", nil, "github.com/username/repo/a.go", "github.com/username/repo/a.go"),
+		Entry("trimmed prefix", "github.com/username/repo", nil, "github.com/username/repo/a.go", "a.go"),
+		Entry("trimmed root package", "github.com/username/repo", nil, "github.com/username/repo", "."),
+		Entry("rules after trim", "github.com/username/",
+			[]config.RewriteRule{{Pattern: `^repo/internal/(\w+)/`, Replace: "$1/"}}, "github.com/username/repo/internal/db/a.go", "db/a.go"),
+		Entry("rules in order", "",
+			[]config.RewriteRule{{Pattern: "a", Replace: "b"}, {Pattern: "b", Replace: "c"}}, "a.go", "c.go"),
+	)
+
+	It("Should show the import paths when nil", func() {
+		var names *config.DisplayNames
+		Expect(names.Name("github.com/username/repo/a.go")).To(Equal("github.com/username/repo/a.go"))
+	})
+
+	It("Should reject invalid patterns", func() {
+		cfg := config.Default
+		cfg.Display.Rewrite = []config.RewriteRule{{Pattern: "(unclosed"}}
+
+		err := cfg.Validate()
+		Expect(err).To(MatchError(ContainSubstring("display.rewrite[0].pattern: error parsing regexp")))
+
+		var fieldErr *config.FieldError
+		Expect(errors.As(err, &fieldErr)).To(BeTrue())
+	})
+
+	It("Should parse the rules of the options", func() {
+		cfg := config.Default
+		Expect(config.Apply(&cfg, map[string][]string{"rewrite": {`^internal/(.*)=$1`}})).To(Succeed())
+		Expect(cfg.Display.Rewrite).To(Equal([]config.RewriteRule{{Pattern: "^internal/(.*)", Replace: "$1"}}))
+
+		Expect(config.Apply(&cfg, map[string][]string{"rewrite": {"internal"}})).To(MatchError(ContainSubstring("pattern=replacement")))
+	})
+
+	Context("Links", func() {
+		links := config.Links{
+			Repo:    "username/repo",
+			SHA:     "abc123",
+			File:    "https://github.com/{repo}/blob/{sha}/{path}",
+			Package: "https://github.com/{repo}/tree/{sha}/{path}",
+			Lines:   "https://github.com/{repo}/blob/{sha}/{path}#L{line}-L{end}",
+			Report:  "https://example.com/{repo}/{sha}/coverage.html",
+		}
+
+		It("Should link the source relative to the repository root", func() {
+			cfg := config.Default
+			cfg.RootPackage = "github.com/username/repo"
+			cfg.Modules = []config.Module{{Path: "github.com/username/repo/tools", Dir: "build/tools"}}
+			cfg.Display.Links = links
+
+			names, err := cfg.DisplayNames()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(names.FileURL("github.com/username/repo/pkg/a.go")).To(Equal("https://github.com/username/repo/blob/abc123/pkg/a.go"))
+			Expect(names.PackageURL("github.com/username/repo/tools/gen")).To(Equal("https://github.com/username/repo/tree/abc123/build/tools/gen"))
+			Expect(names.LinesURL("github.com/username/repo/pkg/a.go", 3, 7)).To(Equal("https://github.com/username/repo/blob/abc123/pkg/a.go#L3-L7"))
+			Expect(names.ReportURL()).To(Equal("https://example.com/username/repo/abc123/coverage.html"))
+			Expect(names.HasLines()).To(BeTrue())
+		})
+
+		It("Should not link without template", func() {
+			cfg := config.Default
+			names, err := cfg.DisplayNames()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(names.FileURL("github.com/username/repo/pkg/a.go")).To(BeEmpty())
+			Expect(names.HasLines()).To(BeFalse())
+
+			names = nil
+			Expect(names.ReportURL()).To(BeEmpty())
+		})
+
+		It("Should reject unknown variables", func() {
+			cfg := config.Default
+			cfg.Display.Links = config.Links{File: "https://{host}/{path}", Report: "https://example.com/{path}"}
+
+			err := cfg.Validate()
+			Expect(errors.Is(err, config.ErrUnknownVariable)).To(BeTrue())
+			Expect(err.Error()).To(Equal(`display.links.file: has an unknown variable {host}
+display.links.report: has an unknown variable {path}`))
+		})
+	})
+})
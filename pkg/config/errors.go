@@ -1,7 +1,37 @@
 package config
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrThresholdNotInRange = errors.New("threshold must be in range [0 - 100]")
+	ErrUnknownPreset       = errors.New("is not a known preset")
+	ErrScoreBandBoundary   = errors.New("must define exactly one of 'below' or 'above'")
+	ErrScoreBandNegative   = errors.New("must not have negative 'step' or 'max'")
+	ErrPercentNotInRange   = errors.New("percentage must be in range [0 - 100]")
+	ErrNegative            = errors.New("must not be negative")
+	ErrMissing             = errors.New("is required")
+	ErrDuplicate           = errors.New("is defined more than once")
+	ErrUnknownVariable     = errors.New("has an unknown variable")
+	ErrUnknownPlatform     = errors.New("is not a known platform")
 )
+
+// FieldError is a validation error of a single configuration field. When the
+// configuration was read from a file, Line and Column locate the field.
+type FieldError struct {
+	Field        string
+	Line, Column int
+	Err          error
+}
+
+func (e *FieldError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d, column %d: %s: %v", e.Line, e.Column, e.Field, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
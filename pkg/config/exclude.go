@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	RuleRegex     = "regex"
+	RuleGlob      = "glob"
+	RuleGitignore = "gitignore"
+)
+
+// PathRule is a single exclude rule. In YAML it is either a mapping with a
+// pattern and a type, or a plain string which may be prefixed with its type
+// (e.g. "glob:**/*_mock.go"); strings without prefix are regular expressions.
+type PathRule struct {
+	Pattern string `yaml:"pattern"`
+	Type    string `yaml:"type"`
+}
+
+// ParsePathRule parses the string form of a rule.
+func ParsePathRule(s string) PathRule {
+	for _, t := range []string{RuleRegex, RuleGlob, RuleGitignore} {
+		if pattern, ok := strings.CutPrefix(s, t+":"); ok {
+			return PathRule{Pattern: pattern, Type: t}
+		}
+	}
+
+	return PathRule{Pattern: s, Type: RuleRegex}
+}
+
+func (r *PathRule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*r = ParsePathRule(node.Value)
+		return nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: exclude rule must be a string or a mapping", node.Line)
+	}
+
+	// decoded by hand, as KnownFields is not applied to custom unmarshalers
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		switch key.Value {
+		case "pattern":
+			r.Pattern = value.Value
+		case "type":
+			r.Type = value.Value
+		default:
+			return fmt.Errorf("line %d: field %s not found in exclude rule", key.Line, key.Value)
+		}
+	}
+
+	if r.Type == "" {
+		r.Type = RuleRegex
+	}
+
+	return nil
+}
+
+// PathMatcher reports whether a file or package path is excluded. Rules are
+// evaluated in order and the last matching rule wins, so a negated gitignore
+// rule re-includes paths excluded before it. A nil matcher excludes nothing.
+type PathMatcher struct {
+	root  string
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	negate bool
+	// relative rules are matched against the path relative to the root
+	// package, regular expressions against the full path.
+	relative bool
+	match    func(name string) bool
+}
+
+func (m *PathMatcher) Match(name string) bool {
+	if m == nil {
+		return false
+	}
+
+	var (
+		rel      = relativeTo(name, m.root)
+		excluded = false
+	)
+
+	for _, r := range m.rules {
+		target := name
+		if r.relative {
+			target = rel
+		}
+
+		if r.match(target) {
+			excluded = !r.negate
+		}
+	}
+
+	return excluded
+}
+
+// Matcher compiles the exclude rules. Glob and gitignore patterns are matched
+// against paths relative to root, when the path is inside of it.
+func (e Exclude) Matcher(root string) (*PathMatcher, error) {
+	m := &PathMatcher{root: strings.TrimSuffix(root, "/"), rules: make([]compiledRule, 0, len(e.Paths))}
+
+	for i, rule := range e.Paths {
+		compiled, err := rule.compile()
+		if err != nil {
+			return nil, &FieldError{Field: fmt.Sprintf("exclude.paths[%d]", i), Err: err}
+		}
+
+		m.rules = append(m.rules, compiled)
+	}
+
+	return m, nil
+}
+
+func (e Exclude) validate() error {
+	_, err := e.Matcher("")
+	return err
+}
+
+func (r PathRule) compile() (compiledRule, error) {
+	switch r.Type {
+	case RuleRegex, "":
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return compiledRule{}, err
+		}
+
+		return compiledRule{match: re.MatchString}, nil
+	case RuleGlob:
+		if !doublestar.ValidatePattern(r.Pattern) {
+			return compiledRule{}, fmt.Errorf("invalid glob pattern %q", r.Pattern)
+		}
+
+		return compiledRule{relative: true, match: func(name string) bool {
+			ok, _ := doublestar.Match(r.Pattern, name)
+			return ok
+		}}, nil
+	case RuleGitignore:
+		return compileGitignore(r.Pattern)
+	default:
+		return compiledRule{}, fmt.Errorf("unknown rule type %q", r.Type)
+	}
+}
+
+// compileGitignore follows the .gitignore rules: a leading "!" negates the
+// pattern, a trailing "/" only matches directories, a pattern without a slash
+// matches at any depth and a pattern matching a directory matches everything
+// below it.
+func compileGitignore(pattern string) (compiledRule, error) {
+	rule := compiledRule{relative: true}
+
+	if p, ok := strings.CutPrefix(pattern, "!"); ok {
+		rule.negate, pattern = true, p
+	}
+
+	pattern = strings.TrimPrefix(pattern, `\`)
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	if pattern == "" || !doublestar.ValidatePattern(pattern) {
+		return compiledRule{}, fmt.Errorf("invalid gitignore pattern %q", pattern)
+	}
+
+	// the names are those of the profiled files, whose last segment is the
+	// only one which is not a directory
+	rule.match = func(name string) bool {
+		segments := strings.Split(name, "/")
+
+		for i := len(segments); i > 0; i-- {
+			if dirOnly && i == len(segments) {
+				continue
+			}
+
+			if ok, _ := doublestar.Match(pattern, strings.Join(segments[:i], "/")); ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return rule, nil
+}
+
+func relativeTo(name, root string) string {
+	if root == "" {
+		return name
+	}
+
+	if name == root {
+		return "."
+	}
+
+	if rel, ok := strings.CutPrefix(name, root+"/"); ok {
+		return rel
+	}
+
+	return name
+}
@@ -0,0 +1,55 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+)
+
+var _ = Describe("Exclude", func() {
+	const root = "github.com/username/repo"
+
+	matcher := func(rules ...string) *config.PathMatcher {
+		exclude := config.Exclude{}
+		for _, r := range rules {
+			exclude.Paths = append(exclude.Paths, config.ParsePathRule(r))
+		}
+
+		m, err := exclude.Matcher(root)
+		Expect(err).ToNot(HaveOccurred())
+
+		return m
+	}
+
+	DescribeTable("Match",
+		func(rules []string, name string, expected bool) {
+			Expect(matcher(rules...).Match(name)).To(Equal(expected))
+		},
+		Entry("regex on full path", []string{`\.pb\.go$`}, root+"/api/user.pb.go", true),
+		Entry("glob relative to root", []string{"glob:**/*_mock.go"}, root+"/pkg/db/store_mock.go", true),
+		Entry("glob is anchored", []string{"glob:pkg/*.go"}, root+"/internal/pkg/a.go", false),
+		Entry("gitignore without slash matches at any depth", []string{"gitignore:mocks"}, root+"/a/mocks/m.go", true),
+		Entry("gitignore with slash is anchored", []string{"gitignore:/mocks"}, root+"/a/mocks/m.go", false),
+		Entry("gitignore directory matches packages", []string{"gitignore:internal/"}, root+"/internal/x", true),
+		Entry("gitignore directory does not match files", []string{"gitignore:*.go/"}, root+"/a.go", false),
+		Entry("gitignore directory does not match other files", []string{"gitignore:asm_amd64.s/"}, root+"/a/asm_amd64.s", false),
+		Entry("gitignore directory matches the parents of other files", []string{"gitignore:testdata/"}, root+"/a/testdata/in.txt", true),
+		Entry("gitignore negation re-includes",
+			[]string{"gitignore:internal/**", "gitignore:!internal/keep/**"}, root+"/internal/keep/a.go", false),
+		Entry("gitignore negation keeps other excludes",
+			[]string{"gitignore:internal/**", "gitignore:!internal/keep/**"}, root+"/internal/drop/a.go", true),
+		Entry("last matching rule wins",
+			[]string{"gitignore:!internal/keep/**", "glob:internal/**"}, root+"/internal/keep/a.go", true),
+	)
+
+	It("Should not exclude anything when nil", func() {
+		var m *config.PathMatcher
+		Expect(m.Match(root)).To(BeFalse())
+	})
+
+	It("Should reject unknown rule types", func() {
+		_, err := config.Exclude{Paths: []config.PathRule{{Pattern: "a", Type: "fuzzy"}}}.Matcher(root)
+		Expect(err).To(MatchError(`exclude.paths[0]: unknown rule type "fuzzy"`))
+	})
+})
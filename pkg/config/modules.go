@@ -0,0 +1,99 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Module configures a module of a multi-module repository, such as a go.work
+// workspace. The report shows the coverage of each module next to the
+// combined one.
+type Module struct {
+	// Path is the module path, as found in the go.mod file.
+	Path string `yaml:"path"`
+	// Dir is the directory of the module, relative to Source. It is used to
+	// read the source of the module.
+	Dir string `yaml:"dir"`
+	// Threshold overrides the global thresholds for the files of the module;
+	// unset values inherit the global ones.
+	Threshold ModuleThreshold `yaml:"threshold"`
+}
+
+// ModuleThreshold overrides the global thresholds for a module. Nil values
+// inherit the global ones, while 0 disables the check for the module.
+type ModuleThreshold struct {
+	File      *int `yaml:"file"`
+	Package   *int `yaml:"package"`
+	Directory *int `yaml:"directory"`
+	Total     *int `yaml:"total"`
+	Branch    *int `yaml:"branch"`
+}
+
+func validateModules(modules []Module) error {
+	var (
+		errs []error
+		seen = make(map[string]bool, len(modules))
+	)
+
+	for i, m := range modules {
+		field := fmt.Sprintf("modules[%d]", i)
+
+		switch {
+		case m.Path == "":
+			errs = append(errs, &FieldError{Field: field + ".path", Err: ErrMissing})
+		case seen[m.Path]:
+			errs = append(errs, &FieldError{Field: field + ".path", Err: ErrDuplicate})
+		}
+
+		seen[m.Path] = true
+
+		errs = append(errs, m.Threshold.inherit(Threshold{}).validate(field+".threshold"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ModuleOf returns the module holding a file or package, matching the longest
+// module path.
+func (c *Config) ModuleOf(name string) (Module, bool) {
+	var (
+		res   Module
+		found bool
+	)
+
+	for _, m := range c.Modules {
+		if (name == m.Path || strings.HasPrefix(name, m.Path+"/")) && len(m.Path) >= len(res.Path) {
+			res, found = m, true
+		}
+	}
+
+	return res, found
+}
+
+// ThresholdOf returns the thresholds applying to a file or package: those of
+// its module, falling back to the global ones for unset values.
+func (c *Config) ThresholdOf(name string) Threshold {
+	m, ok := c.ModuleOf(name)
+	if !ok {
+		return c.Threshold
+	}
+
+	return m.Threshold.inherit(c.Threshold)
+}
+
+func (c ModuleThreshold) inherit(parent Threshold) Threshold {
+	for _, t := range []struct{ value, parent *int }{
+		{c.File, &parent.File},
+		{c.Package, &parent.Package},
+		{c.Directory, &parent.Directory},
+		{c.Total, &parent.Total},
+		{c.Branch, &parent.Branch},
+	} {
+		if t.value != nil {
+			*t.parent = *t.value
+		}
+	}
+
+	return parent
+}
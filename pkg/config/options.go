@@ -0,0 +1,364 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is the prefix of every environment variable read by ApplyEnv.
+const EnvPrefix = "GO_COVERAGE_REPORT_"
+
+// FileNames lists the configuration file names looked up by Discover.
+var FileNames = []string{".testcoverage.yaml", ".testcoverage.yml"}
+
+// Option describes a configuration setting that can be given both as command
+// line flag and as environment variable, in addition to the config file.
+type Option struct {
+	Name  string
+	Usage string
+	// List options accept repeated flags; their environment variable holds
+	// one value per line.
+	List bool
+	// Bool options may be given as flag without value.
+	Bool bool
+	Set  func(cfg *Config, values []string) error
+}
+
+// Env returns the name of the environment variable of the option.
+func (o Option) Env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(o.Name, "-", "_"))
+}
+
+// Options lists every setting of Config that can be overridden from the
+// command line or the environment. Precedence is flag > env > file > default.
+var Options = []Option{
+	{
+		Name:  "root",
+		Usage: "The import path of the tested repository to add as prefix to all paths of the changed files",
+		Set:   setString(func(cfg *Config) *string { return &cfg.RootPackage }),
+	},
+	{
+		Name:  "trim",
+		Usage: "trim a prefix from the file and package names shown by the reports",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Trim }),
+	},
+	{
+		Name:  "rewrite",
+		Usage: "rewrite the file and package names shown by the reports as `pattern=replacement`, pattern being a regexp; can be repeated",
+		List:  true,
+		Set: func(cfg *Config, values []string) error {
+			rules := make([]RewriteRule, len(values))
+
+			for i, v := range values {
+				rule, err := ParseRewriteRule(v)
+				if err != nil {
+					return err
+				}
+
+				rules[i] = rule
+			}
+
+			cfg.Display.Rewrite = rules
+
+			return nil
+		},
+	},
+	{
+		Name:  "link-repo",
+		Usage: "repository linked by the reports, the {repo} variable of the link templates (e.g. username/example)",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Display.Links.Repo }),
+	},
+	{
+		Name:  "link-sha",
+		Usage: "commit linked by the reports, the {sha} variable of the link templates",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Display.Links.SHA }),
+	},
+	{
+		Name:  "link-file",
+		Usage: "`template` of the links of the files, e.g. https://github.com/{repo}/blob/{sha}/{path}",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Display.Links.File }),
+	},
+	{
+		Name:  "link-package",
+		Usage: "`template` of the links of the packages, e.g. https://github.com/{repo}/tree/{sha}/{path}",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Display.Links.Package }),
+	},
+	{
+		Name:  "link-lines",
+		Usage: "`template` of the links of the uncovered lines, e.g. https://github.com/{repo}/blob/{sha}/{path}#L{line}-L{end}",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Display.Links.Lines }),
+	},
+	{
+		Name:  "link-report",
+		Usage: "`url` of the full coverage report, e.g. an HTML report uploaded by the CI run",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Display.Links.Report }),
+	},
+	{
+		Name:  "format",
+		Usage: "output format written to stdout: 'markdown', 'json', 'cobertura' or 'html'",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Format }),
+	},
+	{
+		Name:  "output",
+		Usage: "write the report as `format=path` (use '-' as path for stdout); can be repeated, overrides -format",
+		List:  true,
+		Set:   setList(func(cfg *Config) *[]string { return &cfg.Outputs }),
+	},
+	{
+		Name:  "comment-platform",
+		Usage: "platform whose comment size limit the markdown report must fit: 'github', 'gitlab' or 'none'",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Comment.Platform }),
+	},
+	{
+		Name:  "comment-limit",
+		Usage: "maximum size of the markdown report in bytes, overriding the limit of the comment platform",
+		Set:   setInt(func(cfg *Config) *int { return &cfg.Comment.Limit }),
+	},
+	{
+		Name:  "threshold-file",
+		Usage: "minimum coverage percentage required for individual files",
+		Set:   setInt(func(cfg *Config) *int { return &cfg.Threshold.File }),
+	},
+	{
+		Name:  "threshold-package",
+		Usage: "minimum coverage percentage required for each package",
+		Set:   setInt(func(cfg *Config) *int { return &cfg.Threshold.Package }),
+	},
+	{
+		Name:  "threshold-directory",
+		Usage: "minimum coverage percentage required for each directory of the coverage tree",
+		Set:   setInt(func(cfg *Config) *int { return &cfg.Threshold.Directory }),
+	},
+	{
+		Name:  "threshold-total",
+		Usage: "minimum overall coverage percentage required",
+		Set:   setInt(func(cfg *Config) *int { return &cfg.Threshold.Total }),
+	},
+	{
+		Name:  "threshold-branch",
+		Usage: "minimum branch coverage percentage required for individual files, approximated from their source",
+		Set:   setInt(func(cfg *Config) *int { return &cfg.Threshold.Branch }),
+	},
+	{
+		Name:  "exclude",
+		Usage: "`rule` excluding file or package paths from the report: a regexp, or a pattern prefixed with 'glob:' or 'gitignore:'; can be repeated",
+		List:  true,
+		Set: func(cfg *Config, values []string) error {
+			cfg.Exclude.Paths = make([]PathRule, len(values))
+			for i, v := range values {
+				cfg.Exclude.Paths[i] = ParsePathRule(v)
+			}
+
+			return nil
+		},
+	},
+	{
+		Name:  "exclude-generated",
+		Usage: "exclude files with the standard \"// Code generated ... DO NOT EDIT.\" header",
+		Bool:  true,
+		Set:   setBool(func(cfg *Config) *bool { return &cfg.Exclude.Generated }),
+	},
+	{
+		Name:  "exclude-directives",
+		Usage: "exclude the code annotated with //coverage:ignore",
+		Bool:  true,
+		Set:   setBool(func(cfg *Config) *bool { return &cfg.Exclude.Directives }),
+	},
+	{
+		Name:  "source",
+		Usage: "`directory` of the module checkout, used to read the source of the profiled files",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Source }),
+	},
+	{
+		Name:  "tree",
+		Usage: "show the coverage rolled up by module, directory, package and file",
+		Bool:  true,
+		Set:   setBool(func(cfg *Config) *bool { return &cfg.Tree.Enabled }),
+	},
+	{
+		Name:  "tree-depth",
+		Usage: "levels of the coverage tree shown below the module, 0 shows all of them",
+		Set:   setInt(func(cfg *Config) *int { return &cfg.Tree.Depth }),
+	},
+	{
+		Name:  "branches",
+		Usage: "approximate the branch coverage of the if, switch and select statements from the source of the changed files",
+		Bool:  true,
+		Set:   setBool(func(cfg *Config) *bool { return &cfg.Branches }),
+	},
+	{
+		Name:  "risk-top",
+		Usage: "number of riskiest uncovered functions listed in the report, ranked by complexity and coverage (CRAP score)",
+		Set:   setInt(func(cfg *Config) *int { return &cfg.Risk.Top }),
+	},
+	{
+		Name:  "risk-max",
+		Usage: "maximum risk (CRAP score) allowed for the changed functions, 0 disables the check",
+		Set:   setFloat(func(cfg *Config) *float64 { return &cfg.Risk.Max }),
+	},
+	{
+		Name:  "hotness",
+		Usage: "analyze the execution counts of count and atomic profiles, showing the code only executed once",
+		Bool:  true,
+		Set:   setBool(func(cfg *Config) *bool { return &cfg.Hotness }),
+	},
+	{
+		Name:  "renames",
+		Usage: "`file` holding the output of 'git diff --name-status -M', whose renames are compared against their previous selves",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Renames.File }),
+	},
+	{
+		Name:  "rename-similarity",
+		Usage: "minimum percentage of similar blocks for a removed and an added file to be reported as renamed, 0 disables the detection",
+		Set:   setInt(func(cfg *Config) *int { return &cfg.Renames.Similarity }),
+	},
+	{
+		Name:  "lenient",
+		Usage: "skip the invalid lines of the coverage profiles and report them as warnings instead of failing",
+		Bool:  true,
+		Set:   setBool(func(cfg *Config) *bool { return &cfg.Lenient }),
+	},
+	{
+		Name:  "workspace",
+		Usage: "read the modules from the go.work file of the source directory and report the coverage of each module",
+		Bool:  true,
+		Set:   setBool(func(cfg *Config) *bool { return &cfg.Workspace }),
+	},
+	{
+		Name:  "symbols",
+		Usage: "symbols preset: 'emoji', 'unicode', 'ascii' or 'none'",
+		Set:   setString(func(cfg *Config) *string { return &cfg.Symbols.Preset }),
+	},
+}
+
+// Apply sets the options present in values, keyed by option name. A list
+// option replaces the value from lower precedence sources as a whole.
+func Apply(cfg *Config, values map[string][]string) error {
+	var errs []error
+
+	for _, opt := range Options {
+		vv, ok := values[opt.Name]
+		if !ok || len(vv) == 0 {
+			continue
+		}
+
+		if err := opt.Set(cfg, vv); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", opt.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ApplyEnv sets the options defined through environment variables. Empty
+// variables are treated as unset.
+func ApplyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	values := make(map[string][]string)
+
+	for _, opt := range Options {
+		v, ok := lookup(opt.Env())
+		if !ok || strings.TrimSpace(v) == "" {
+			continue
+		}
+
+		if !opt.List {
+			values[opt.Name] = []string{strings.TrimSpace(v)}
+			continue
+		}
+
+		for _, line := range strings.Split(v, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				values[opt.Name] = append(values[opt.Name], line)
+			}
+		}
+	}
+
+	return Apply(cfg, values)
+}
+
+// Discover looks for a configuration file in dir and its parents, stopping
+// at the module root (the first directory holding a go.mod file). It returns
+// an empty string when no file is found.
+func Discover(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, name := range FileNames {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+func setString(field func(cfg *Config) *string) func(*Config, []string) error {
+	return func(cfg *Config, values []string) error {
+		*field(cfg) = values[len(values)-1]
+		return nil
+	}
+}
+
+func setInt(field func(cfg *Config) *int) func(*Config, []string) error {
+	return func(cfg *Config, values []string) error {
+		v, err := strconv.Atoi(values[len(values)-1])
+		if err != nil {
+			return fmt.Errorf("invalid number %q", values[len(values)-1])
+		}
+
+		*field(cfg) = v
+
+		return nil
+	}
+}
+
+func setFloat(field func(cfg *Config) *float64) func(*Config, []string) error {
+	return func(cfg *Config, values []string) error {
+		v, err := strconv.ParseFloat(values[len(values)-1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", values[len(values)-1])
+		}
+
+		*field(cfg) = v
+
+		return nil
+	}
+}
+
+func setBool(field func(cfg *Config) *bool) func(*Config, []string) error {
+	return func(cfg *Config, values []string) error {
+		v, err := strconv.ParseBool(values[len(values)-1])
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", values[len(values)-1])
+		}
+
+		*field(cfg) = v
+
+		return nil
+	}
+}
+
+func setList(field func(cfg *Config) *[]string) func(*Config, []string) error {
+	return func(cfg *Config, values []string) error {
+		*field(cfg) = append([]string(nil), values...)
+		return nil
+	}
+}
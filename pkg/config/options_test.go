@@ -0,0 +1,68 @@
+package config_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+)
+
+var _ = Describe("Options", func() {
+	Context("Discover", func() {
+		It("Should find the file in a parent directory", func() {
+			path, err := config.Discover("testdata/nested/module/sub")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(HaveSuffix(filepath.Join("testdata", "nested", "module", ".testcoverage.yaml")))
+		})
+
+		When("the file is outside of the module", func() {
+			It("Should stop at the module root", func() {
+				path, err := config.Discover("testdata/nested/other")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(path).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("Precedence", func() {
+		It("Should prefer flags over env over file over default", func() {
+			cfg := config.Default
+			Expect(config.FromFile(&cfg, "testdata/nested/module/.testcoverage.yaml")).To(Succeed())
+
+			env := map[string]string{
+				"GO_COVERAGE_REPORT_TRIM":            "github.com/",
+				"GO_COVERAGE_REPORT_THRESHOLD_TOTAL": "60",
+				"GO_COVERAGE_REPORT_EXCLUDE":         "_mock\\.go$\n\ngitignore:vendor/\n",
+				"GO_COVERAGE_REPORT_ROOT":            "",
+			}
+			lookup := func(key string) (string, bool) {
+				v, ok := env[key]
+				return v, ok
+			}
+
+			Expect(config.ApplyEnv(&cfg, lookup)).To(Succeed())
+			Expect(config.Apply(&cfg, map[string][]string{
+				"threshold-total": {"70"},
+				"output":          {"markdown=-", "json=report.json"},
+			})).To(Succeed())
+
+			Expect(cfg.RootPackage).To(Equal("github.com/username/module"))
+			Expect(cfg.Trim).To(Equal("github.com/"))
+			Expect(cfg.Format).To(Equal("markdown"))
+			Expect(cfg.Outputs).To(Equal([]string{"markdown=-", "json=report.json"}))
+			Expect(cfg.Threshold).To(Equal(config.Threshold{File: 10, Package: 0, Total: 70}))
+			Expect(cfg.Exclude.Paths).To(Equal([]config.PathRule{
+				{Pattern: "_mock\\.go$", Type: config.RuleRegex},
+				{Pattern: "vendor/", Type: config.RuleGitignore},
+			}))
+		})
+
+		It("Should reject invalid numbers", func() {
+			cfg := config.Default
+			err := config.Apply(&cfg, map[string][]string{"threshold-file": {"abc"}})
+			Expect(err).To(MatchError(ContainSubstring("threshold-file")))
+		})
+	})
+})
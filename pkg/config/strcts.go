@@ -1,35 +1,123 @@
 package config
 
-import "fmt"
+import "errors"
 
 type Config struct {
-	RootPackage string
-	Threshold   Threshold `yaml:"threshold"`
-	Exclude     Exclude   `yaml:"exclude"`
+	RootPackage string `yaml:"root"`
+	// Trim is trimmed from the names shown by the reports, see Display.
+	Trim      string    `yaml:"trim"`
+	Format    string    `yaml:"format"`
+	Outputs   []string  `yaml:"outputs"`
+	Source    string    `yaml:"source"`
+	Threshold Threshold `yaml:"threshold"`
+	Exclude   Exclude   `yaml:"exclude"`
+	Symbols   Symbols   `yaml:"symbols"`
+	Tree      Tree      `yaml:"tree"`
+	Renames   Renames   `yaml:"renames"`
+	Display   Display   `yaml:"display"`
+	Comment   Comment   `yaml:"comment"`
+	// Lenient skips the invalid lines of the profiles, reporting them as
+	// warnings, instead of failing.
+	Lenient bool `yaml:"lenient"`
+	// Hotness analyzes the execution counts of count and atomic profiles.
+	Hotness bool `yaml:"hotness"`
+	Risk    Risk `yaml:"risk"`
+	// Branches approximates the branch coverage from the source of the
+	// changed files; it is implied by a branch threshold.
+	Branches bool `yaml:"branches"`
+	// Workspace reads the modules from the go.work file of Source.
+	Workspace bool     `yaml:"workspace"`
+	Modules   []Module `yaml:"modules"`
 }
 
-type Exclude struct {
-	Paths []string `yaml:"paths"`
+// Tree configures the hierarchical module/directory roll-up of the report.
+type Tree struct {
+	Enabled bool `yaml:"enabled"`
+	// Depth limits the levels shown below the module, 0 shows all of them.
+	Depth int `yaml:"depth"`
 }
 
-type Threshold struct {
-	File    int `yaml:"file"`
-	Package int `yaml:"package"`
-	Total   int `yaml:"total"`
+func (t Tree) validate() error {
+	if t.Depth < 0 {
+		return &FieldError{Field: "tree.depth", Err: ErrNegative}
+	}
+
+	return nil
+}
+
+// Risk configures the risk score of the changed functions, which combines
+// their cyclomatic complexity and their coverage (CRAP score).
+type Risk struct {
+	// Top is the number of riskiest functions with uncovered statements
+	// listed in the report, 0 hides the list.
+	Top int `yaml:"top"`
+	// Max fails the report when a function scores more, 0 disables the
+	// check.
+	Max float64 `yaml:"max"`
 }
 
-func (c Threshold) validate() error {
-	if !inRange(c.File) {
-		return fmt.Errorf("file %w", ErrThresholdNotInRange)
+func (r Risk) validate() error {
+	var errs []error
+
+	if r.Top < 0 {
+		errs = append(errs, &FieldError{Field: "risk.top", Err: ErrNegative})
 	}
 
-	if !inRange(c.Package) {
-		return fmt.Errorf("package %w", ErrThresholdNotInRange)
+	if r.Max < 0 {
+		errs = append(errs, &FieldError{Field: "risk.max", Err: ErrNegative})
 	}
 
-	if !inRange(c.Total) {
-		return fmt.Errorf("total %w", ErrThresholdNotInRange)
+	return errors.Join(errs...)
+}
+
+// Renames configures the detection of files renamed or moved between the old
+// and the new coverage.
+type Renames struct {
+	// File holds the output of "git diff --name-status -M", whose renames
+	// are used as is.
+	File string `yaml:"file"`
+	// Similarity is the minimum percentage of similar blocks for files to
+	// be detected as renamed, 0 disables the detection.
+	Similarity int `yaml:"similarity"`
+}
+
+func (r Renames) validate() error {
+	if !inRange(r.Similarity) {
+		return &FieldError{Field: "renames.similarity", Err: ErrPercentNotInRange}
 	}
 
 	return nil
 }
+
+type Exclude struct {
+	Paths []PathRule `yaml:"paths"`
+	// Generated excludes files with the standard generated code header.
+	Generated bool `yaml:"generated"`
+	// Directives honors the //coverage:ignore comments in the source.
+	Directives bool `yaml:"directives"`
+}
+
+type Threshold struct {
+	File      int `yaml:"file"`
+	Package   int `yaml:"package"`
+	Directory int `yaml:"directory"`
+	Total     int `yaml:"total"`
+	// Branch is the minimum branch coverage of the changed files, see
+	// Config.Branches.
+	Branch int `yaml:"branch"`
+}
+
+func (c Threshold) validate(field string) error {
+	var errs []error
+
+	for _, t := range []struct {
+		field string
+		value int
+	}{{"file", c.File}, {"package", c.Package}, {"directory", c.Directory}, {"total", c.Total}, {"branch", c.Branch}} {
+		if !inRange(t.value) {
+			errs = append(errs, &FieldError{Field: field + "." + t.field, Err: ErrThresholdNotInRange})
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	PresetEmoji   = "emoji"
+	PresetUnicode = "unicode"
+	PresetASCII   = "ascii"
+	PresetNone    = "none"
+)
+
+// Symbols holds the markers used to decorate the report. Empty fields are
+// inherited from the selected preset.
+type Symbols struct {
+	Preset    string      `yaml:"preset"`
+	Pass      string      `yaml:"pass"`
+	Fail      string      `yaml:"fail"`
+	Unchanged string      `yaml:"unchanged"`
+	Trend     string      `yaml:"trend"`
+	Scale     []ScoreBand `yaml:"scale"`
+}
+
+// ScoreBand maps a range of coverage change to a symbol. Exactly one of Below
+// or Above must be set; the band matches when the change is strictly below or
+// above that value. When Step is set, the symbol is repeated once per Step
+// percent of change, up to Max times.
+type ScoreBand struct {
+	Below  *float64 `yaml:"below"`
+	Above  *float64 `yaml:"above"`
+	Symbol string   `yaml:"symbol"`
+	Step   float64  `yaml:"step"`
+	Max    int      `yaml:"max"`
+}
+
+func (b ScoreBand) Matches(diff float64) bool {
+	if b.Below != nil {
+		return diff < *b.Below
+	}
+
+	return b.Above != nil && diff > *b.Above
+}
+
+func (b ScoreBand) validate() error {
+	if (b.Below == nil) == (b.Above == nil) {
+		return ErrScoreBandBoundary
+	}
+
+	if b.Step < 0 || b.Max < 0 {
+		return ErrScoreBandNegative
+	}
+
+	return nil
+}
+
+func ptr(v float64) *float64 { return &v }
+
+var presets = map[string]Symbols{
+	PresetEmoji: {
+		Pass:      ":white_check_mark:",
+		Fail:      ":negative_squared_cross_mark:",
+		Unchanged: "ø",
+		Trend:     ":robot:",
+		Scale: []ScoreBand{
+			{Below: ptr(-10), Symbol: ":skull:", Step: 10, Max: 5},
+			{Below: ptr(0), Symbol: ":thumbsdown:"},
+			{Above: ptr(20), Symbol: ":star2:"},
+			{Above: ptr(10), Symbol: ":tada:"},
+			{Above: ptr(0), Symbol: ":thumbsup:"},
+		},
+	},
+	PresetUnicode: {
+		Pass:      "✅",
+		Fail:      "❎",
+		Unchanged: "ø",
+		Trend:     "🤖",
+		Scale: []ScoreBand{
+			{Below: ptr(-10), Symbol: "💀", Step: 10, Max: 5},
+			{Below: ptr(0), Symbol: "👎"},
+			{Above: ptr(20), Symbol: "🌟"},
+			{Above: ptr(10), Symbol: "🎉"},
+			{Above: ptr(0), Symbol: "👍"},
+		},
+	},
+	PresetASCII: {
+		Pass:      "OK",
+		Fail:      "!!",
+		Unchanged: "=",
+		Trend:     "Trend",
+		Scale: []ScoreBand{
+			{Below: ptr(-10), Symbol: "-", Step: 10, Max: 5},
+			{Below: ptr(0), Symbol: "-"},
+			{Above: ptr(20), Symbol: "+++"},
+			{Above: ptr(10), Symbol: "++"},
+			{Above: ptr(0), Symbol: "+"},
+		},
+	},
+	PresetNone: {
+		Unchanged: "=",
+		Scale:     []ScoreBand{},
+	},
+}
+
+// Resolve returns a copy of the symbols where every unset field is filled
+// from the preset. An empty preset selects PresetEmoji.
+func (s Symbols) Resolve() Symbols {
+	if s.Preset == "" {
+		s.Preset = PresetEmoji
+	}
+
+	preset, ok := presets[s.Preset]
+	if !ok {
+		preset = presets[PresetEmoji]
+	}
+
+	if s.Pass == "" {
+		s.Pass = preset.Pass
+	}
+
+	if s.Fail == "" {
+		s.Fail = preset.Fail
+	}
+
+	if s.Unchanged == "" {
+		s.Unchanged = preset.Unchanged
+	}
+
+	if s.Trend == "" {
+		s.Trend = preset.Trend
+	}
+
+	if s.Scale == nil {
+		s.Scale = preset.Scale
+	}
+
+	return s
+}
+
+func (s Symbols) validate() error {
+	var errs []error
+
+	if _, ok := presets[s.Preset]; s.Preset != "" && !ok {
+		errs = append(errs, &FieldError{Field: "symbols.preset", Err: fmt.Errorf("%q %w", s.Preset, ErrUnknownPreset)})
+	}
+
+	for i, b := range s.Scale {
+		if err := b.validate(); err != nil {
+			errs = append(errs, &FieldError{Field: fmt.Sprintf("symbols.scale[%d]", i), Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
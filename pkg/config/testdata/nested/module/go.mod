@@ -0,0 +1 @@
+module github.com/username/module
@@ -0,0 +1,313 @@
+package coverage
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+)
+
+// Branches approximates the branch coverage of a file from its profile: the
+// branches of the if, switch and select statements are mapped to the blocks
+// of the profile.
+type Branches struct {
+	Total   int
+	Covered int
+}
+
+func (b Branches) Percent() float64 {
+	if b.Total == 0 {
+		return 0
+	}
+
+	return float64(b.Covered) / float64(b.Total) * 100
+}
+
+// Add returns the sum of both branch coverages.
+func (b Branches) Add(o Branches) Branches {
+	return Branches{Total: b.Total + o.Total, Covered: b.Covered + o.Covered}
+}
+
+// Branches returns the branch coverage of a profile, or false if the source
+// of the file can't be read. Each clause of an if, switch or select statement
+// is a branch, taken if its first block was executed. The implicit else of an
+// if, or default of a switch, is taken if the statement was executed more
+// often than its clauses; for set profiles, which don't record counts, if
+// none of the clauses was executed, or if the code following the statement
+// was executed while every executed clause ends with a terminating statement.
+// Statements ignored through IgnoreDirective are skipped.
+func (s *Source) Branches(p Profile) (Branches, bool) {
+	var res Branches
+
+	path, ok := s.Path(p.FileName)
+	if !ok {
+		return res, false
+	}
+
+	src, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return res, false
+	}
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return res, false
+	}
+
+	var (
+		ignored = s.file(p.FileName).ignored
+		blocks  = profileBlocks{fset: fset, blocks: p.Blocks, counts: HasCounts(p.Mode), ignored: ignored}
+		// parents holds the enclosing nodes of the current one
+		parents []ast.Node
+	)
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			parents = parents[:len(parents)-1]
+			return false
+		}
+
+		if blocks.isIgnored(n) {
+			return false
+		}
+
+		limit := enclosingEnd(parents)
+
+		switch stmt := n.(type) {
+		case *ast.IfStmt:
+			res = res.Add(blocks.ifBranches(stmt, limit))
+		case *ast.SwitchStmt:
+			res = res.Add(blocks.clauseBranches(stmt, stmt.Body, true, limit))
+		case *ast.TypeSwitchStmt:
+			res = res.Add(blocks.clauseBranches(stmt, stmt.Body, true, limit))
+		case *ast.SelectStmt:
+			res = res.Add(blocks.clauseBranches(stmt, stmt.Body, false, limit))
+		}
+
+		parents = append(parents, n)
+
+		return true
+	})
+
+	return res, true
+}
+
+// enclosingEnd returns the end of the innermost statement list holding the
+// current node.
+func enclosingEnd(parents []ast.Node) token.Pos {
+	for i := len(parents) - 1; i >= 0; i-- {
+		switch parents[i].(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+			return parents[i].End()
+		}
+	}
+
+	return token.NoPos
+}
+
+type profileBlocks struct {
+	fset    *token.FileSet
+	blocks  []ProfileBlock
+	counts  bool
+	ignored []posRange
+}
+
+// isIgnored tells whether the node is ignored through IgnoreDirective.
+func (b profileBlocks) isIgnored(n ast.Node) bool {
+	start, end := b.fset.Position(n.Pos()), b.fset.Position(n.End())
+
+	for _, r := range b.ignored {
+		if before(r.start.Line, r.start.Column, start.Line, start.Column) &&
+			before(end.Line, end.Column, r.end.Line, r.end.Column) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// at returns the execution count of the innermost block holding pos.
+func (b profileBlocks) at(pos token.Pos) (int, bool) {
+	var (
+		p     = b.fset.Position(pos)
+		count int
+		found bool
+	)
+
+	for _, block := range b.blocks {
+		if before(block.StartLine, block.StartCol, p.Line, p.Column) &&
+			before(p.Line, p.Column, block.EndLine, block.EndCol) {
+			count, found = block.ExecCount, true
+		}
+	}
+
+	return count, found
+}
+
+// first returns the execution count of the first block starting between
+// from and to.
+func (b profileBlocks) first(from, to token.Pos) (int, bool) {
+	start, end := b.fset.Position(from), b.fset.Position(to)
+
+	for _, block := range b.blocks {
+		if before(start.Line, start.Column, block.StartLine, block.StartCol) &&
+			before(block.StartLine, block.StartCol, end.Line, end.Column) {
+			return block.ExecCount, true
+		}
+	}
+
+	return 0, false
+}
+
+func (b profileBlocks) ifBranches(stmt *ast.IfStmt, limit token.Pos) Branches {
+	var (
+		res       Branches
+		body, _   = b.first(stmt.Body.Lbrace, stmt.Body.Rbrace)
+		elseTaken bool
+	)
+
+	if !b.isIgnored(stmt.Body) {
+		res.Total++
+		if body > 0 {
+			res.Covered++
+		}
+	}
+
+	if stmt.Else != nil && b.isIgnored(stmt.Else) {
+		return res
+	}
+
+	switch e := stmt.Else.(type) {
+	case *ast.BlockStmt:
+		count, _ := b.first(e.Lbrace, e.Rbrace)
+		elseTaken = count > 0
+	case *ast.IfStmt:
+		elseTaken = b.reached(e) > 0
+	default:
+		elseTaken = b.implicit(stmt, limit, b.reached(stmt), []int{body}, [][]ast.Stmt{stmt.Body.List})
+	}
+
+	res.Total++
+	if elseTaken {
+		res.Covered++
+	}
+
+	return res
+}
+
+func (b profileBlocks) clauseBranches(stmt ast.Stmt, body *ast.BlockStmt, implicitDefault bool, limit token.Pos) Branches {
+	var (
+		res        Branches
+		counts     []int
+		lists      [][]ast.Stmt
+		hasDefault bool
+	)
+
+	for _, clause := range body.List {
+		var (
+			from token.Pos
+			list []ast.Stmt
+		)
+
+		switch c := clause.(type) {
+		case *ast.CaseClause:
+			from, list, hasDefault = c.Colon, c.Body, hasDefault || c.List == nil
+		case *ast.CommClause:
+			from, list = c.Colon, c.Body
+		}
+
+		count, _ := b.first(from, clause.End())
+		counts, lists = append(counts, count), append(lists, list)
+
+		if b.isIgnored(clause) {
+			continue
+		}
+
+		res.Total++
+		if count > 0 {
+			res.Covered++
+		}
+	}
+
+	if implicitDefault && !hasDefault {
+		res.Total++
+		if b.implicit(stmt, limit, b.reached(stmt), counts, lists) {
+			res.Covered++
+		}
+	}
+
+	return res
+}
+
+// reached returns the execution count of the block evaluating the statement
+// header.
+func (b profileBlocks) reached(stmt ast.Stmt) int {
+	count, _ := b.at(stmt.Pos())
+	return count
+}
+
+// implicit tells whether the implicit else or default path of stmt was taken,
+// given the execution counts and statements of its clauses. The code
+// following stmt is looked up until limit.
+func (b profileBlocks) implicit(stmt ast.Stmt, limit token.Pos, reached int, counts []int, lists [][]ast.Stmt) bool {
+	taken := 0
+	for _, c := range counts {
+		taken += c
+	}
+
+	if b.counts {
+		return reached > taken
+	}
+
+	if reached == 0 {
+		return false
+	}
+
+	if taken == 0 {
+		return true
+	}
+
+	if !limit.IsValid() {
+		return false
+	}
+
+	next, ok := b.first(stmt.End(), limit)
+	if !ok || next == 0 {
+		return false
+	}
+
+	for i, list := range lists {
+		if counts[i] > 0 && !terminates(list) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// terminates tells whether a statement list ends with a return, a panic or a
+// branch statement.
+func terminates(list []ast.Stmt) bool {
+	if len(list) == 0 {
+		return false
+	}
+
+	switch s := list[len(list)-1].(type) {
+	case *ast.ReturnStmt, *ast.BranchStmt:
+		return true
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+
+		ident, ok := call.Fun.(*ast.Ident)
+
+		return ok && ident.Name == "panic"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,49 @@
+package coverage_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Branches", func() {
+	var src *coverage.Source
+
+	BeforeEach(func() {
+		var err error
+
+		src, err = coverage.FindSource("testdata/source")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("Should map the branches to the blocks",
+		func(profile string, expected coverage.Branches) {
+			cov, err := coverage.NewCoverageFromFile(profile)
+			Expect(err).NotTo(HaveOccurred())
+
+			branches, ok := src.Branches(cov.Files["example.com/source/branch/branch.go"])
+			Expect(ok).To(BeTrue())
+			Expect(branches).To(Equal(expected))
+		},
+		Entry("with counts", "testdata/04-branch-count-coverage.txt", coverage.Branches{Total: 15, Covered: 11}),
+		Entry("without counts", "testdata/04-branch-set-coverage.txt", coverage.Branches{Total: 15, Covered: 10}),
+	)
+
+	It("Should skip the ignored branches", func() {
+		cov, err := coverage.NewCoverageFromFile("testdata/03-source-coverage.txt")
+		Expect(err).NotTo(HaveOccurred())
+
+		src.Directives = true
+
+		branches, ok := src.Branches(cov.Files["example.com/source/calc/calc.go"])
+		Expect(ok).To(BeTrue())
+		Expect(branches).To(Equal(coverage.Branches{Total: 4, Covered: 3}))
+		Expect(branches.Percent()).To(BeNumerically("==", 75))
+	})
+
+	It("Should not analyze files outside of the module", func() {
+		_, ok := src.Branches(coverage.Profile{FileName: "example.com/other/file.go"})
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -1,8 +1,11 @@
 package coverage
 
 import (
+	"errors"
 	"fmt"
-	"path"
+	"io"
+	"io/fs"
+	"sort"
 )
 
 type Coverage struct {
@@ -10,6 +13,14 @@ type Coverage struct {
 	TotalStmt   int
 	CoveredStmt int
 	MissedStmt  int
+	// ExcludedStmt counts the statements excluded because of their source,
+	// including those of ExcludedFiles.
+	ExcludedStmt int
+	// ExcludedFiles holds the files dropped from Files because of their
+	// source, keyed by file name.
+	ExcludedFiles map[string]ExcludedFile `json:",omitempty"`
+	// Diagnostics holds the invalid lines skipped by lenient parsing.
+	Diagnostics []Diagnostic `json:",omitempty"`
 }
 
 func NewCoverage(profiles []Profile) *Coverage {
@@ -21,13 +32,134 @@ func NewCoverage(profiles []Profile) *Coverage {
 	return &cov
 }
 
+// NewCoverageFromFile parses the profile in the specified file, or the
+// standard input for StdinPath. Compressed profiles are decompressed.
 func NewCoverageFromFile(filename string) (*Coverage, error) {
-	pp, err := NewProfilesFromFile(filename)
+	return newCoverage(parseProfilesFromFile(filename, ParseOptions{}))
+}
+
+// NewCoverageFromReader parses the profile read from rd, which may be
+// compressed.
+func NewCoverageFromReader(rd io.Reader, opts ParseOptions) (*Coverage, error) {
+	return newCoverage(ParseProfiles(rd, opts))
+}
+
+func newCoverage(pp []Profile, diags []Diagnostic, err error) (*Coverage, error) {
 	if err != nil {
 		return nil, err
 	}
 
-	return NewCoverage(pp), nil
+	cov := NewCoverage(pp)
+	cov.Diagnostics = diags
+
+	return cov, nil
+}
+
+// NewCoverageFromFiles parses several profiles, e.g. one per module of a
+// workspace, and merges them into a single Coverage.
+func NewCoverageFromFiles(filenames ...string) (*Coverage, error) {
+	return NewCoverageFromFilesWithOptions(ParseOptions{}, filenames...)
+}
+
+// NewCoverageFromFilesWithOptions is NewCoverageFromFiles parsing the
+// profiles with opts; the diagnostics of lenient parsing are kept in the
+// Coverage.
+func NewCoverageFromFilesWithOptions(opts ParseOptions, filenames ...string) (*Coverage, error) {
+	return mergeProfiles(filenames, func(name string) ([]Profile, []Diagnostic, error) {
+		return parseProfilesFromFile(name, opts)
+	})
+}
+
+// NewCoverageFromFS parses the named profiles of fsys, e.g. the files of a
+// zip archive, and merges them into a single Coverage.
+func NewCoverageFromFS(fsys fs.FS, opts ParseOptions, names ...string) (*Coverage, error) {
+	return mergeProfiles(names, func(name string) ([]Profile, []Diagnostic, error) {
+		return parseProfilesFromFS(fsys, name, opts)
+	})
+}
+
+// mergeProfiles parses the named profiles with parse and merges them.
+func mergeProfiles(names []string, parse func(name string) ([]Profile, []Diagnostic, error)) (*Coverage, error) {
+	covs := make([]*Coverage, 0, len(names))
+
+	for _, name := range names {
+		cov, err := newCoverage(parse(name))
+		if err != nil {
+			// A ParseError already names the profile.
+			if perr := (*ParseError)(nil); !errors.As(err, &perr) {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+
+			return nil, err
+		}
+
+		covs = append(covs, cov)
+	}
+
+	return Merge(covs...)
+}
+
+// Merge combines several coverages. The blocks of a file found in more than
+// one coverage are merged like the blocks of a single profile.
+func Merge(covs ...*Coverage) (*Coverage, error) {
+	var (
+		files    = make(map[string]Profile)
+		excluded = make(map[string]ExcludedFile)
+		diags    []Diagnostic
+	)
+
+	for _, cov := range covs {
+		diags = append(diags, cov.Diagnostics...)
+
+		for name, p := range cov.Files {
+			if prev, ok := files[name]; ok {
+				p.Blocks = append(append([]ProfileBlock(nil), prev.Blocks...), p.Blocks...)
+				p.ExcludedStmt = max(p.ExcludedStmt, prev.ExcludedStmt)
+			}
+
+			files[name] = p
+		}
+
+		for name, f := range cov.ExcludedFiles {
+			excluded[name] = f
+		}
+	}
+
+	profiles := make([]Profile, 0, len(files))
+
+	for name, p := range files {
+		sort.Sort(blocksByStart(p.Blocks))
+
+		blocks, err := mergeBlocks(p.Mode, p.Blocks, func(_, from, to int) error {
+			return fmt.Errorf("inconsistent NumStmt: changed from %d to %d", from, to)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		p.Blocks = blocks
+		p.count()
+
+		profiles = append(profiles, p)
+	}
+
+	res := NewCoverage(profiles)
+	res.Diagnostics = diags
+
+	for name, f := range excluded {
+		if _, ok := res.Files[name]; ok {
+			continue
+		}
+
+		if res.ExcludedFiles == nil {
+			res.ExcludedFiles = make(map[string]ExcludedFile)
+		}
+
+		res.ExcludedFiles[name] = f
+		res.ExcludedStmt += f.Stmt
+	}
+
+	return res, nil
 }
 
 func (c *Coverage) add(p Profile) {
@@ -39,6 +171,7 @@ func (c *Coverage) add(p Profile) {
 	c.TotalStmt += p.TotalStmt
 	c.CoveredStmt += p.CoveredStmt
 	c.MissedStmt += p.MissedStmt
+	c.ExcludedStmt += p.ExcludedStmt
 }
 
 func (c *Coverage) Percent() float64 {
@@ -53,7 +186,7 @@ func (c *Coverage) ByPackage() map[string]*Coverage {
 	packages := make(map[string][]string)
 
 	for file := range c.Files {
-		pkg := path.Dir(file)
+		pkg := PackagePath(file)
 		packages[pkg] = append(packages[pkg], file)
 	}
 
@@ -71,10 +204,86 @@ func (c *Coverage) ByPackage() map[string]*Coverage {
 	return pkgCovs
 }
 
-func (c *Coverage) TrimPrefix(prefix string) {
-	for name, cov := range c.Files {
-		delete(c.Files, cov.FileName)
-		cov.FileName = TrimPrefix(name, prefix)
-		c.Files[cov.FileName] = cov
+// Filter returns a view of the coverage holding only the files accepted by
+// keep, with the totals and packages computed from those files only. The
+// coverage itself is left untouched.
+func (c *Coverage) Filter(keep func(fileName string) bool) *Coverage {
+	profiles := make([]Profile, 0, len(c.Files))
+
+	for name, p := range c.Files {
+		if keep(name) {
+			profiles = append(profiles, p)
+		}
 	}
+
+	res := NewCoverage(profiles)
+	res.Diagnostics = c.Diagnostics
+
+	for name, f := range c.ExcludedFiles {
+		if !keep(name) {
+			continue
+		}
+
+		if res.ExcludedFiles == nil {
+			res.ExcludedFiles = make(map[string]ExcludedFile)
+		}
+
+		res.ExcludedFiles[name] = f
+		res.ExcludedStmt += f.Stmt
+	}
+
+	return res
+}
+
+// MergeOverlaps returns a copy of the coverage whose partially overlapping
+// blocks are merged, see Merge for the blocks at the same position.
+func (c *Coverage) MergeOverlaps() *Coverage {
+	profiles := make([]Profile, 0, len(c.Files))
+
+	for _, p := range c.Files {
+		p.Blocks = mergeOverlaps(p.Mode, p.Blocks)
+		p.count()
+
+		profiles = append(profiles, p)
+	}
+
+	res := NewCoverage(profiles)
+	res.Diagnostics = c.Diagnostics
+
+	if c.ExcludedFiles != nil {
+		res.ExcludedFiles = make(map[string]ExcludedFile, len(c.ExcludedFiles))
+	}
+
+	for name, f := range c.ExcludedFiles {
+		res.ExcludedFiles[name] = f
+		res.ExcludedStmt += f.Stmt
+	}
+
+	return res
+}
+
+// TrimPrefix returns a copy of the coverage whose file names are trimmed of
+// the prefix, e.g. to write a profile with relative names. Reports keep the
+// full names, see config.Display.
+func (c *Coverage) TrimPrefix(prefix string) *Coverage {
+	profiles := make([]Profile, 0, len(c.Files))
+
+	for name, p := range c.Files {
+		p.FileName = TrimPrefix(name, prefix)
+		profiles = append(profiles, p)
+	}
+
+	res := NewCoverage(profiles)
+	res.Diagnostics = c.Diagnostics
+
+	if c.ExcludedFiles != nil {
+		res.ExcludedFiles = make(map[string]ExcludedFile, len(c.ExcludedFiles))
+	}
+
+	for name, f := range c.ExcludedFiles {
+		res.ExcludedFiles[TrimPrefix(name, prefix)] = f
+		res.ExcludedStmt += f.Stmt
+	}
+
+	return res
 }
@@ -1,6 +1,8 @@
 package coverage_test
 
 import (
+	"strings"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -36,4 +38,97 @@ var _ = Describe("Coverage", func() {
 			})
 		})
 	})
+	Context("Filter", func() {
+		It("Should compute the totals of the kept files only", func() {
+			cov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			filtered := cov.Filter(func(fileName string) bool {
+				return fileName != "github.com/username/prioqueue/min_heap.go"
+			})
+
+			Expect(filtered.Files).To(HaveLen(len(cov.Files) - 1))
+			Expect(filtered.TotalStmt).To(Equal(50))
+			Expect(filtered.CoveredStmt).To(Equal(50))
+			Expect(filtered.Percent()).To(BeNumerically("==", 100))
+			Expect(filtered.ByPackage()["github.com/username/prioqueue"].TotalStmt).To(Equal(50))
+
+			Expect(cov.TotalStmt).To(Equal(102), "the coverage must not be modified")
+		})
+	})
+
+	Context("TrimPrefix", func() {
+		It("Should return a copy with the trimmed file names", func() {
+			cov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			trimmed := cov.TrimPrefix("github.com/username/prioqueue")
+			Expect(trimmed.Files).To(HaveKey("min_heap.go"))
+			Expect(trimmed.TotalStmt).To(Equal(102))
+
+			Expect(cov.Files).To(HaveKey("github.com/username/prioqueue/min_heap.go"), "the coverage must not be modified")
+		})
+	})
+
+	Context("MergeOverlaps", func() {
+		It("Should merge the partially overlapping blocks into a block spanning them", func() {
+			cov, err := coverage.NewCoverageFromReader(strings.NewReader("mode: set\n"+
+				"example.com/app/main.go:1.1,2.2 1 0\n"+
+				"example.com/app/main.go:3.10,5.2 2 0\n"+
+				"example.com/app/main.go:4.1,6.2 3 1\n"+
+				"example.com/app/main.go:5.1,5.9 1 0\n"+
+				"example.com/app/main.go:6.2,7.1 1 0\n",
+			), coverage.ParseOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			merged := cov.MergeOverlaps()
+			Expect(merged.Files["example.com/app/main.go"].Blocks).To(Equal([]coverage.ProfileBlock{
+				{StartLine: 1, StartCol: 1, EndLine: 2, EndCol: 2, NumStmt: 1, ExecCount: 0},
+				{StartLine: 3, StartCol: 10, EndLine: 6, EndCol: 2, NumStmt: 3, ExecCount: 1},
+				{StartLine: 6, StartCol: 2, EndLine: 7, EndCol: 1, NumStmt: 1, ExecCount: 0},
+			}))
+			Expect(merged.TotalStmt).To(Equal(5))
+			Expect(merged.CoveredStmt).To(Equal(3))
+
+			Expect(cov.TotalStmt).To(Equal(8), "the coverage must not be modified")
+		})
+	})
+
+	Context("NewCoverageFromFiles", func() {
+		It("Should merge the profiles of several modules", func() {
+			cov, err := coverage.NewCoverageFromFiles("testdata/01-new-coverage.txt", "testdata/03-source-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			single, err := coverage.NewCoverageFromFile("testdata/03-source-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cov.Files).To(HaveLen(len(single.Files) + 2))
+			Expect(cov.TotalStmt).To(Equal(102 + single.TotalStmt))
+			Expect(cov.CoveredStmt).To(Equal(92 + single.CoveredStmt))
+		})
+
+		It("Should merge the blocks of the files found in several profiles", func() {
+			cov, err := coverage.NewCoverageFromFiles("testdata/01-new-coverage.txt", "testdata/01-new-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			single, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cov.TotalStmt).To(Equal(102))
+			Expect(cov.CoveredStmt).To(Equal(92))
+
+			for name, p := range cov.Files {
+				Expect(p.Blocks).To(HaveLen(len(single.Files[name].Blocks)))
+
+				for i, b := range p.Blocks {
+					Expect(b.ExecCount).To(Equal(2*single.Files[name].Blocks[i].ExecCount), "count mode sums the executions")
+				}
+			}
+		})
+
+		It("Should fail on a missing profile", func() {
+			_, err := coverage.NewCoverageFromFiles("testdata/01-new-coverage.txt", "testdata/missing.txt")
+			Expect(err).To(MatchError(ContainSubstring("testdata/missing.txt")))
+		})
+	})
 })
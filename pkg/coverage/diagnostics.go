@@ -0,0 +1,73 @@
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Reason tells why a line of a profile is invalid.
+type Reason string
+
+const (
+	ReasonFormat   Reason = "bad format"
+	ReasonNegative Reason = "negative value"
+	ReasonNumStmt  Reason = "inconsistent NumStmt"
+	ReasonMismatch Reason = "mode mismatch"
+)
+
+// errNegativeValue is wrapped by the errors of the negative numbers of a line.
+var errNegativeValue = errors.New("negative values are not allowed")
+
+// Diagnostic describes an invalid line of a profile.
+type Diagnostic struct {
+	// Profile is the path of the profile, when read from a file.
+	Profile string `json:",omitempty"`
+	Line    int
+	// FileName is the source file of the line, when it could be parsed.
+	FileName string `json:",omitempty"`
+	Reason   Reason
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	var b strings.Builder
+
+	if d.Profile != "" {
+		_, _ = fmt.Fprintf(&b, "%s:%d: ", d.Profile, d.Line)
+	} else {
+		_, _ = fmt.Fprintf(&b, "line %d: ", d.Line)
+	}
+
+	if d.FileName != "" {
+		_, _ = fmt.Fprintf(&b, "%s: ", d.FileName)
+	}
+
+	_, _ = fmt.Fprintf(&b, "%s: %s", d.Reason, d.Message)
+
+	return b.String()
+}
+
+// ParseError is returned by the strict parsing of a profile for its first
+// invalid line.
+type ParseError struct {
+	Diagnostic
+}
+
+func (e *ParseError) Error() string {
+	return e.Diagnostic.String()
+}
+
+// withProfile sets the profile of the diagnostics and of a ParseError.
+func withProfile(profile string, diags []Diagnostic, err error) ([]Diagnostic, error) {
+	for i := range diags {
+		diags[i].Profile = profile
+	}
+
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		perr.Profile = profile
+	}
+
+	return diags, err
+}
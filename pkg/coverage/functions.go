@@ -0,0 +1,164 @@
+package coverage
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Function is a function of a profiled file, with its cyclomatic complexity
+// and coverage.
+type Function struct {
+	// Name is the function name, prefixed by its receiver type for methods,
+	// e.g. "(*Heap).Push".
+	Name               string
+	StartLine, EndLine int
+	Complexity         int
+	TotalStmt          int
+	CoveredStmt        int
+}
+
+func (f Function) Percent() float64 {
+	if f.TotalStmt == 0 {
+		return 0
+	}
+
+	return float64(f.CoveredStmt) / float64(f.TotalStmt) * 100
+}
+
+// Risk is the CRAP score of the function: complexity² × (1 - coverage)³ +
+// complexity. A fully covered function scores its complexity, an uncovered
+// one complexity² + complexity.
+func (f Function) Risk() float64 {
+	uncovered := 1.0
+	if f.TotalStmt > 0 {
+		uncovered = 1 - float64(f.CoveredStmt)/float64(f.TotalStmt)
+	}
+
+	c := float64(f.Complexity)
+
+	return c*c*math.Pow(uncovered, 3) + c
+}
+
+// Functions returns the functions declared in a profiled file, or false if
+// its source can't be read. Functions ignored through IgnoreDirective are
+// skipped.
+func (s *Source) Functions(p Profile) ([]Function, bool) {
+	path, ok := s.Path(p.FileName)
+	if !ok {
+		return nil, false
+	}
+
+	src, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, false
+	}
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, false
+	}
+
+	var (
+		res    []Function
+		blocks = profileBlocks{fset: fset, blocks: p.Blocks, ignored: s.file(p.FileName).ignored}
+	)
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || blocks.isIgnored(fn) {
+			continue
+		}
+
+		f := Function{
+			Name:       funcName(fn),
+			StartLine:  fset.Position(fn.Pos()).Line,
+			EndLine:    fset.Position(fn.End()).Line,
+			Complexity: complexity(fn.Body),
+		}
+
+		start, end := fset.Position(fn.Body.Lbrace), fset.Position(fn.Body.Rbrace)
+
+		for _, b := range p.Blocks {
+			if before(start.Line, start.Column, b.StartLine, b.StartCol) && before(b.EndLine, b.EndCol, end.Line, end.Column+1) {
+				f.TotalStmt += b.NumStmt
+
+				if b.ExecCount > 0 {
+					f.CoveredStmt += b.NumStmt
+				}
+			}
+		}
+
+		res = append(res, f)
+	}
+
+	return res, true
+}
+
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	var (
+		typ     = fn.Recv.List[0].Type
+		pointer bool
+	)
+
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ, pointer = star.X, true
+	}
+
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		typ = t.X
+	case *ast.IndexListExpr:
+		typ = t.X
+	}
+
+	name := "?"
+	if ident, ok := typ.(*ast.Ident); ok {
+		name = ident.Name
+	}
+
+	if pointer {
+		return "(*" + name + ")." + fn.Name.Name
+	}
+
+	return name + "." + fn.Name.Name
+}
+
+// complexity returns the cyclomatic complexity of a function body: one plus
+// the number of decision points, i.e. if, for and range statements, non
+// default case and select clauses, and && and || operators.
+func complexity(body *ast.BlockStmt) int {
+	res := 1
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			res++
+		case *ast.CaseClause:
+			if n.List != nil {
+				res++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				res++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				res++
+			}
+		}
+
+		return true
+	})
+
+	return res
+}
@@ -0,0 +1,53 @@
+package coverage_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Functions", func() {
+	It("Should compute the complexity and coverage of each function", func() {
+		src, err := coverage.FindSource("testdata/source")
+		Expect(err).NotTo(HaveOccurred())
+
+		cov, err := coverage.NewCoverageFromFile("testdata/04-branch-count-coverage.txt")
+		Expect(err).NotTo(HaveOccurred())
+
+		functions, ok := src.Functions(cov.Files["example.com/source/branch/branch.go"])
+		Expect(ok).To(BeTrue())
+		Expect(functions).To(Equal([]coverage.Function{
+			{Name: "Clamp", StartLine: 4, EndLine: 14, Complexity: 3, TotalStmt: 5, CoveredStmt: 4},
+			{Name: "Abs", StartLine: 17, EndLine: 23, Complexity: 2, TotalStmt: 3, CoveredStmt: 3},
+			{Name: "Kind", StartLine: 26, EndLine: 35, Complexity: 3, TotalStmt: 4, CoveredStmt: 3},
+			{Name: "Parity", StartLine: 38, EndLine: 46, Complexity: 3, TotalStmt: 5, CoveredStmt: 4},
+			{Name: "Recv", StartLine: 49, EndLine: 56, Complexity: 2, TotalStmt: 3, CoveredStmt: 2},
+		}))
+	})
+
+	It("Should skip the ignored functions", func() {
+		src, err := coverage.FindSource("testdata/source")
+		Expect(err).NotTo(HaveOccurred())
+
+		cov, err := coverage.NewCoverageFromFile("testdata/03-source-coverage.txt")
+		Expect(err).NotTo(HaveOccurred())
+
+		src.Directives = true
+
+		functions, ok := src.Functions(cov.Files["example.com/source/calc/calc.go"])
+		Expect(ok).To(BeTrue())
+		Expect(functions).To(HaveLen(3))
+		Expect(functions[2].Name).To(Equal("Sign"))
+	})
+
+	DescribeTable("Risk",
+		func(f coverage.Function, expected float64) {
+			Expect(f.Risk()).To(BeNumerically("~", expected, 0.001))
+		},
+		Entry("covered", coverage.Function{Complexity: 4, TotalStmt: 4, CoveredStmt: 4}, 4.0),
+		Entry("uncovered", coverage.Function{Complexity: 4, TotalStmt: 4}, 20.0),
+		Entry("half covered", coverage.Function{Complexity: 4, TotalStmt: 4, CoveredStmt: 2}, 6.0),
+		Entry("without statements", coverage.Function{Complexity: 1}, 2.0),
+	)
+})
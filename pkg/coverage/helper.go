@@ -2,6 +2,8 @@ package coverage
 
 import "strings"
 
+// TrimPrefix trims the prefix and the following slash from an import path,
+// the path of the prefix itself becoming ".".
 func TrimPrefix(name, prefix string) string {
 	trimmed := strings.TrimPrefix(name, prefix)
 	trimmed = strings.TrimPrefix(trimmed, "/")
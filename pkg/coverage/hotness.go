@@ -0,0 +1,137 @@
+package coverage
+
+import "fmt"
+
+// HasCounts reports whether a profile mode records execution counts, i.e.
+// "count" or "atomic", rather than whether a block was executed.
+func HasCounts(mode string) bool {
+	return mode == "count" || mode == "atomic"
+}
+
+// Hotness distributes the statements of a profile by execution count.
+type Hotness struct {
+	Missed int
+	// Once counts the statements executed only once, whose coverage is
+	// likely incidental.
+	Once int
+	Few  int // executed 2-9 times
+	Many int // executed 10-99 times
+	Hot  int // executed 100 times or more
+
+	MaxCount int
+	// OnceLines lists the line ranges of the blocks executed only once.
+	OnceLines []LineRange `json:",omitempty"`
+}
+
+// LineRange is an inclusive range of lines.
+type LineRange struct {
+	Start, End int
+}
+
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return fmt.Sprintf("%d", r.Start)
+	}
+
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// UncoveredLines returns the line ranges of the blocks never executed.
+func (p Profile) UncoveredLines() []LineRange {
+	var res []LineRange
+
+	for _, b := range p.Blocks {
+		if b.ExecCount == 0 && b.NumStmt > 0 {
+			res = appendLines(res, LineRange{Start: b.StartLine, End: b.EndLine})
+		}
+	}
+
+	return res
+}
+
+// Hotness returns the execution count distribution of the profile, or false
+// if its mode doesn't record counts.
+func (p Profile) Hotness() (Hotness, bool) {
+	var res Hotness
+
+	if !HasCounts(p.Mode) {
+		return res, false
+	}
+
+	for _, b := range p.Blocks {
+		switch {
+		case b.ExecCount == 0:
+			res.Missed += b.NumStmt
+		case b.ExecCount == 1:
+			res.Once += b.NumStmt
+			res.OnceLines = appendLines(res.OnceLines, LineRange{Start: b.StartLine, End: b.EndLine})
+		case b.ExecCount < 10:
+			res.Few += b.NumStmt
+		case b.ExecCount < 100:
+			res.Many += b.NumStmt
+		default:
+			res.Hot += b.NumStmt
+		}
+
+		res.MaxCount = max(res.MaxCount, b.ExecCount)
+	}
+
+	return res, true
+}
+
+// appendLines adds r to the sorted ranges, merging overlapping or adjacent
+// ranges.
+func appendLines(ranges []LineRange, r LineRange) []LineRange {
+	if n := len(ranges); n > 0 && r.Start <= ranges[n-1].End+1 {
+		ranges[n-1].End = max(ranges[n-1].End, r.End)
+		return ranges
+	}
+
+	return append(ranges, r)
+}
+
+// CountChanges compares the execution counts of the blocks found at the same
+// position in both profiles.
+type CountChanges struct {
+	Increased, Decreased int
+	// Lost counts the blocks no longer executed.
+	Lost int
+}
+
+// CompareCounts compares the execution counts of the blocks of a file in the
+// old and the new profile.
+func CompareCounts(oldProfile, newProfile Profile) CountChanges {
+	var (
+		res  CountChanges
+		olds = make(map[ProfileBlock]int, len(oldProfile.Blocks))
+	)
+
+	for _, b := range oldProfile.Blocks {
+		olds[b.position()] = b.ExecCount
+	}
+
+	for _, b := range newProfile.Blocks {
+		oldCount, ok := olds[b.position()]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case b.ExecCount == 0 && oldCount > 0:
+			res.Lost++
+			res.Decreased++
+		case b.ExecCount < oldCount:
+			res.Decreased++
+		case b.ExecCount > oldCount:
+			res.Increased++
+		}
+	}
+
+	return res
+}
+
+// position returns the block without its execution count.
+func (p ProfileBlock) position() ProfileBlock {
+	p.ExecCount = 0
+	return p
+}
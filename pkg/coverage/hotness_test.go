@@ -0,0 +1,57 @@
+package coverage_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Hotness", func() {
+	blocks := []coverage.ProfileBlock{
+		{StartLine: 1, EndLine: 2, NumStmt: 2, ExecCount: 0},
+		{StartLine: 3, EndLine: 4, NumStmt: 1, ExecCount: 1},
+		{StartLine: 5, EndLine: 6, NumStmt: 3, ExecCount: 1},
+		{StartLine: 8, EndLine: 8, NumStmt: 1, ExecCount: 1},
+		{StartLine: 10, EndLine: 11, NumStmt: 2, ExecCount: 5},
+		{StartLine: 12, EndLine: 13, NumStmt: 1, ExecCount: 42},
+		{StartLine: 14, EndLine: 15, NumStmt: 4, ExecCount: 100},
+	}
+
+	It("Should distribute the statements by execution count", func() {
+		h, ok := coverage.Profile{Mode: "atomic", Blocks: blocks}.Hotness()
+		Expect(ok).To(BeTrue())
+		Expect(h).To(Equal(coverage.Hotness{
+			Missed: 2, Once: 5, Few: 2, Many: 1, Hot: 4, MaxCount: 100,
+			OnceLines: []coverage.LineRange{{Start: 3, End: 6}, {Start: 8, End: 8}},
+		}))
+		Expect(h.OnceLines[0].String()).To(Equal("3-6"))
+		Expect(h.OnceLines[1].String()).To(Equal("8"))
+	})
+
+	It("Should merge the uncovered lines", func() {
+		uncovered := append([]coverage.ProfileBlock(nil), blocks...)
+		uncovered[1].ExecCount = 0
+		uncovered[3].ExecCount = 0
+
+		Expect(coverage.Profile{Mode: "set", Blocks: uncovered}.UncoveredLines()).To(Equal([]coverage.LineRange{
+			{Start: 1, End: 4}, {Start: 8, End: 8},
+		}))
+	})
+
+	It("Should ignore profiles without counts", func() {
+		_, ok := coverage.Profile{Mode: "set", Blocks: blocks}.Hotness()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Should compare the counts of the blocks", func() {
+		newBlocks := append([]coverage.ProfileBlock(nil), blocks...)
+		newBlocks[1].ExecCount = 0
+		newBlocks[4].ExecCount = 2
+		newBlocks[6].ExecCount = 150
+		newBlocks = append(newBlocks, coverage.ProfileBlock{StartLine: 20, EndLine: 21, NumStmt: 1, ExecCount: 3})
+
+		changes := coverage.CompareCounts(coverage.Profile{Blocks: blocks}, coverage.Profile{Blocks: newBlocks})
+		Expect(changes).To(Equal(coverage.CountChanges{Increased: 1, Decreased: 2, Lost: 1}))
+	})
+})
@@ -0,0 +1,95 @@
+package coverage
+
+import (
+	"go/build"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// Package identifies the package of a profiled file.
+type Package struct {
+	ImportPath string
+	Name       string
+}
+
+// PackagePath returns the import path of the package holding a file. Profiles
+// and changed files may use either separator, the result always uses slashes
+// so that both agree.
+func PackagePath(fileName string) string {
+	return path.Dir(strings.ReplaceAll(fileName, `\`, "/"))
+}
+
+// Packages resolves the packages of profiled files. With Sources, package
+// names are read from the package clauses of the files selected by the build
+// constraints of the current platform, ignoring external test packages.
+// Without a Source, or for packages outside of the modules, the name is
+// derived from the import path.
+type Packages struct {
+	srcs  []*Source
+	names map[string]string
+}
+
+// NewPackages returns a resolver reading the package names from the given
+// Sources, e.g. one per module of a workspace. Nil Sources are ignored.
+func NewPackages(srcs ...*Source) *Packages {
+	return &Packages{srcs: srcs, names: make(map[string]string)}
+}
+
+// Names returns the names of the packages with the given import paths, keyed
+// by import path.
+func (p *Packages) Names(importPaths []string) map[string]string {
+	res := make(map[string]string, len(importPaths))
+	for _, importPath := range importPaths {
+		res[importPath] = p.Name(importPath)
+	}
+
+	return res
+}
+
+// Of returns the package of a profiled file.
+func (p *Packages) Of(fileName string) Package {
+	importPath := PackagePath(fileName)
+	return Package{ImportPath: importPath, Name: p.Name(importPath)}
+}
+
+// Name returns the name of the package with the given import path.
+func (p *Packages) Name(importPath string) string {
+	if name, ok := p.names[importPath]; ok {
+		return name
+	}
+
+	name := p.resolve(importPath)
+	p.names[importPath] = name
+
+	return name
+}
+
+func (p *Packages) resolve(importPath string) string {
+	for _, src := range p.srcs {
+		if src == nil {
+			continue
+		}
+
+		if dir, ok := src.PackageDir(importPath); ok {
+			// on a MultiplePackageError the first package found is returned
+			pkg, _ := build.ImportDir(dir, 0)
+			if pkg != nil && pkg.Name != "" {
+				return pkg.Name
+			}
+		}
+	}
+
+	return nameFromPath(importPath)
+}
+
+func nameFromPath(importPath string) string {
+	name := path.Base(importPath)
+	if majorVersion.MatchString(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+
+	return name
+}
@@ -0,0 +1,38 @@
+package coverage_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Packages", func() {
+	DescribeTable("PackagePath",
+		func(fileName, expected string) {
+			Expect(coverage.PackagePath(fileName)).To(Equal(expected))
+		},
+		Entry("profile file", "github.com/username/repo/foo/bar.go", "github.com/username/repo/foo"),
+		Entry("windows separators", `github.com\username\repo\foo\bar.go`, "github.com/username/repo/foo"),
+	)
+
+	Context("Of", func() {
+		It("Should read the package name from the source", func() {
+			src, err := coverage.FindSource("testdata/source")
+			Expect(err).NotTo(HaveOccurred())
+
+			packages := coverage.NewPackages(src)
+			Expect(packages.Of("example.com/source/named/other.go")).To(Equal(coverage.Package{
+				ImportPath: "example.com/source/named",
+				Name:       "other",
+			}))
+			Expect(packages.Name("example.com/source/calc")).To(Equal("calc"))
+		})
+
+		It("Should fall back to the import path", func() {
+			packages := coverage.NewPackages(nil)
+			Expect(packages.Name("example.com/source/named")).To(Equal("named"))
+			Expect(packages.Name("example.com/module/v2")).To(Equal("module"))
+		})
+	})
+})
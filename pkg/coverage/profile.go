@@ -2,14 +2,14 @@ package coverage
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"math"
 	"sort"
 	"strconv"
-	"strings"
+	"sync"
 )
 
 // Profile represents the profiling data for a specific file.
@@ -21,6 +21,9 @@ type Profile struct {
 	TotalStmt   int
 	CoveredStmt int
 	MissedStmt  int
+	// ExcludedStmt counts the statements of blocks removed by source
+	// directives; they are not part of the other totals.
+	ExcludedStmt int
 }
 
 // ProfileBlock represents a single block of profiling data.
@@ -39,19 +42,13 @@ func (p ProfileBlock) Equal(b ProfileBlock) bool {
 		p.NumStmt == b.NumStmt
 }
 
-// NewProfilesFromFile parses profile data in the specified file and returns a
-// Profile for each source file described therein.
+// NewProfilesFromFile parses profile data in the specified file, or the
+// standard input for StdinPath, and returns a Profile for each source file
+// described therein.
 func NewProfilesFromFile(fileName string) ([]Profile, error) {
-	pf, err := os.Open(filepath.Clean(fileName))
-	if err != nil {
-		return nil, err
-	}
+	profiles, _, err := parseProfilesFromFile(fileName, ParseOptions{})
 
-	defer func() {
-		_ = pf.Close()
-	}()
-
-	return ParseProfilesFromReader(pf)
+	return profiles, err
 }
 
 func (p Profile) CoveragePercent() float64 {
@@ -74,110 +71,358 @@ func (p Profile) GetMissed() int {
 	return p.MissedStmt
 }
 
+// count computes the statement totals from the blocks.
+func (p *Profile) count() {
+	p.TotalStmt, p.CoveredStmt = 0, 0
+
+	for _, b := range p.Blocks {
+		p.TotalStmt += b.NumStmt
+
+		if b.ExecCount > 0 {
+			p.CoveredStmt += b.NumStmt
+		}
+	}
+
+	p.MissedStmt = p.TotalStmt - p.CoveredStmt
+}
+
+// initialLineSize is the initial size of the line buffer of the parser; the
+// buffer grows as needed for longer lines.
+const initialLineSize = 64 * 1024
+
+// ParseOptions tunes the parsing of profiles.
+type ParseOptions struct {
+	// Workers is the number of goroutines sorting and merging the blocks of
+	// the files once the profile is read; the blocks are normalized
+	// sequentially when it is lower than 2.
+	Workers int
+	// Lenient skips the invalid lines and reports them as diagnostics,
+	// instead of failing with a ParseError for the first one.
+	Lenient bool
+}
+
 // ParseProfilesFromReader parses profile data from the Reader and
 // returns a Profile for each source file described therein.
-func ParseProfilesFromReader(rd io.Reader) ([]Profile, error) { //nolint:funlen,gocognit // expected
+func ParseProfilesFromReader(rd io.Reader) ([]Profile, error) {
+	profiles, _, err := ParseProfiles(rd, ParseOptions{})
+
+	return profiles, err
+}
+
+// parsedProfile is a Profile being parsed, along with the line of each block.
+type parsedProfile struct {
+	Profile
+	lines []int
+}
+
+// ParseProfiles parses profile data from the Reader in a single pass and
+// returns a Profile for each source file described therein. Lines are read
+// without copies nor length limit, and the blocks are accumulated in place
+// for each file. Profiles compressed with gzip or zstd are decompressed.
+//
+// Concatenated profiles are accepted as long as their mode lines agree; the
+// blocks following a different mode are invalid. The diagnostics of the
+// invalid lines are only returned in lenient mode, otherwise the first one
+// is returned as a *ParseError.
+func ParseProfiles(rd io.Reader, opts ParseOptions) ([]Profile, []Diagnostic, error) { //nolint:funlen,gocognit // expected
 	var (
-		mode  string
-		files = make(map[string]Profile)
-		s     = bufio.NewScanner(rd)
+		mode   string
+		skip   bool
+		lineNo int
+		diags  []Diagnostic
+		files  = make(map[string]*parsedProfile)
+		last   *parsedProfile
 	)
 
+	rd, closeReader, err := decompress(rd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer closeReader()
+
+	s := bufio.NewScanner(rd)
+
+	report := func(d Diagnostic) error {
+		d.Line = lineNo
+		if !opts.Lenient {
+			return &ParseError{Diagnostic: d}
+		}
+
+		diags = append(diags, d)
+
+		return nil
+	}
+
+	s.Buffer(make([]byte, initialLineSize), math.MaxInt)
+
 	for s.Scan() {
-		line := s.Text()
+		line := s.Bytes()
+		lineNo++
 
-		if isFirstLine := mode == ""; isFirstLine {
-			const p = "mode: "
+		const p = "mode: "
 
-			if !strings.HasPrefix(line, p) || line == p {
-				return nil, fmt.Errorf("bad mode line: %v", line)
+		if bytes.HasPrefix(line, []byte(p)) || mode == "" && !skip {
+			lineMode, reason, err := parseMode(line, p, mode)
+			if err == nil {
+				mode, skip = lineMode, false
+				continue
 			}
 
-			mode = line[len(p):]
+			// The blocks following an invalid mode line are dropped along
+			// with it.
+			skip = true
+
+			if err := report(Diagnostic{Reason: reason, Message: err.Error()}); err != nil {
+				return nil, nil, err
+			}
 
 			continue
 		}
 
+		if skip {
+			continue
+		}
+
 		fileName, block, err := parseLine(line)
 		if err != nil {
-			return nil, fmt.Errorf("line %q doesn't match expected format: %v", line, err)
+			reason := ReasonFormat
+			if errors.Is(err, errNegativeValue) {
+				reason = ReasonNegative
+			}
+
+			if err := report(Diagnostic{FileName: lineFileName(line), Reason: reason, Message: err.Error()}); err != nil {
+				return nil, nil, err
+			}
+
+			continue
 		}
 
-		if profile, exist := files[fileName]; !exist {
-			files[fileName] = Profile{
-				FileName: fileName,
-				Mode:     mode,
-				Blocks:   []ProfileBlock{block},
+		// Blocks of a file are usually contiguous, so the last profile saves
+		// most of the map lookups.
+		if last == nil || last.FileName != string(fileName) {
+			p, exist := files[string(fileName)]
+			if !exist {
+				p = &parsedProfile{Profile: Profile{FileName: string(fileName), Mode: mode}}
+				files[p.FileName] = p
 			}
-		} else {
-			profile.Blocks = append(profile.Blocks, block)
-			files[fileName] = profile
+
+			last = p
 		}
-	}
 
-	if err := s.Err(); err != nil {
-		return nil, err
+		last.Blocks = append(last.Blocks, block)
+		last.lines = append(last.lines, lineNo)
 	}
 
-	profiles := make([]Profile, 0, len(files))
+	if err = s.Err(); err != nil {
+		return nil, nil, err
+	}
 
+	profiles := make([]*parsedProfile, 0, len(files))
 	for _, p := range files {
-		sort.Sort(blocksByStart(p.Blocks))
+		profiles = append(profiles, p)
+	}
+
+	sort.Sort(byFileName(profiles))
+
+	merged, err := normalize(profiles, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diags = append(diags, merged...)
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Line < diags[j].Line })
+
+	res := make([]Profile, len(profiles))
+	for i, p := range profiles {
+		res[i] = p.Profile
+	}
+
+	return res, diags, nil
+}
+
+// lineFileName returns the file name of an invalid line, if any.
+func lineFileName(line []byte) string {
+	if i := bytes.LastIndexByte(line, ':'); i > 0 {
+		return string(line[:i])
+	}
+
+	return ""
+}
+
+// parseMode parses a mode line, which must agree with the current mode.
+func parseMode(line []byte, prefix, mode string) (string, Reason, error) {
+	if !bytes.HasPrefix(line, []byte(prefix)) || len(line) == len(prefix) {
+		return "", ReasonFormat, fmt.Errorf("bad mode line: %s", line)
+	}
+
+	if lineMode := string(line[len(prefix):]); mode == "" || lineMode == mode {
+		return lineMode, "", nil
+	}
+
+	return "", ReasonMismatch, fmt.Errorf("%q differs from %q", line[len(prefix):], mode)
+}
+
+// normalize sorts and merges the blocks of the profiles and counts their
+// statements, with up to opts.Workers goroutines. The diagnostics are
+// returned in the order of the profiles, as is the error of the first
+// profile failing, whatever the order they are normalized in.
+func normalize(profiles []*parsedProfile, opts ParseOptions) ([]Diagnostic, error) {
+	var (
+		diags = make([][]Diagnostic, len(profiles))
+		errs  = make([]error, len(profiles))
+	)
+
+	apply := func(i int) {
+		p := profiles[i]
+
+		sort.Stable(numberedBlocks{blocks: p.Blocks, lines: p.lines})
+
+		p.Blocks, errs[i] = mergeBlocks(p.Mode, p.Blocks, func(b, from, to int) error {
+			d := Diagnostic{
+				Line:     p.lines[b],
+				FileName: p.FileName,
+				Reason:   ReasonNumStmt,
+				Message:  fmt.Sprintf("changed from %d to %d", from, to),
+			}
+			if !opts.Lenient {
+				return &ParseError{Diagnostic: d}
+			}
+
+			diags[i] = append(diags[i], d)
+
+			return nil
+		})
+		p.lines = nil
+		p.count()
+	}
 
-		n := len(p.Blocks)
-		newBlocks := make([]ProfileBlock, 0)
+	if opts.Workers < 2 {
+		for i := range profiles {
+			apply(i)
+		}
+	} else {
+		var (
+			wg      sync.WaitGroup
+			indexes = make(chan int)
+		)
 
-		for l, r := 0, 0; l < n; l++ {
-			r = l
-			startLine, endLine := p.Blocks[l].StartLine, p.Blocks[l].EndLine
-			startCol, endCol := p.Blocks[l].StartCol, p.Blocks[l].EndCol
-			curBlock := p.Blocks[l]
-			execCount := curBlock.ExecCount
+		for range min(opts.Workers, len(profiles)) {
+			wg.Add(1)
 
-			for r+1 < n && (startLine == p.Blocks[r+1].StartLine && endLine == p.Blocks[r+1].EndLine &&
-				startCol == p.Blocks[r+1].StartCol && endCol == p.Blocks[r+1].EndCol) {
-				nextBlock := p.Blocks[r+1]
+			go func() {
+				defer wg.Done()
 
-				if nextBlock.NumStmt != curBlock.NumStmt {
-					return nil, fmt.Errorf("inconsistent NumStmt: changed from %d to %d", curBlock.NumStmt, nextBlock.NumStmt)
+				for i := range indexes {
+					apply(i)
 				}
+			}()
+		}
+
+		for i := range profiles {
+			indexes <- i
+		}
+
+		close(indexes)
+		wg.Wait()
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var res []Diagnostic
+	for _, d := range diags {
+		res = append(res, d...)
+	}
+
+	return res, nil
+}
 
-				if mode == "set" {
-					execCount |= nextBlock.ExecCount
-				} else {
-					execCount += nextBlock.ExecCount
+// mergeBlocks merges the counts of the sorted blocks sharing the same
+// position, as produced by tests run for several packages. The blocks whose
+// NumStmt differs from the first block at their position are passed to
+// inconsistent along with their index and both NumStmt: they are dropped
+// unless it returns an error, which aborts the merge. Partially overlapping
+// blocks are kept as is, see mergeOverlaps.
+func mergeBlocks(mode string, blocks []ProfileBlock, inconsistent func(i, from, to int) error) ([]ProfileBlock, error) {
+	var (
+		n   = len(blocks)
+		res = make([]ProfileBlock, 0, n)
+	)
+
+	for l, r := 0, 0; l < n; l++ {
+		r = l
+		startLine, endLine := blocks[l].StartLine, blocks[l].EndLine
+		startCol, endCol := blocks[l].StartCol, blocks[l].EndCol
+		curBlock := blocks[l]
+		execCount := curBlock.ExecCount
+
+		for r+1 < n && (startLine == blocks[r+1].StartLine && endLine == blocks[r+1].EndLine &&
+			startCol == blocks[r+1].StartCol && endCol == blocks[r+1].EndCol) {
+			nextBlock := blocks[r+1]
+			r++
+
+			if nextBlock.NumStmt != curBlock.NumStmt {
+				if err := inconsistent(r, curBlock.NumStmt, nextBlock.NumStmt); err != nil {
+					return nil, err
 				}
 
-				r++
+				continue
 			}
 
-			curBlock.ExecCount = execCount
-			newBlocks = append(newBlocks, curBlock)
-			l = r
+			if mode == "set" {
+				execCount |= nextBlock.ExecCount
+			} else {
+				execCount += nextBlock.ExecCount
+			}
 		}
 
-		for _, b := range newBlocks {
-			p.TotalStmt += b.NumStmt
+		curBlock.ExecCount = execCount
+		res = append(res, curBlock)
+		l = r
+	}
 
-			if b.ExecCount > 0 {
-				p.CoveredStmt += b.NumStmt
-			}
+	return res, nil
+}
+
+// mergeOverlaps merges the sorted blocks partially overlapping each other,
+// e.g. from test binaries built from different versions of a file, into a
+// block spanning them. The merged block holds the most statements of the
+// blocks and their merged counts, like the blocks at the same position.
+func mergeOverlaps(mode string, blocks []ProfileBlock) []ProfileBlock {
+	res := make([]ProfileBlock, 0, len(blocks))
+
+	for _, b := range blocks {
+		last := len(res) - 1
+		if last < 0 || before(res[last].EndLine, res[last].EndCol, b.StartLine, b.StartCol) {
+			res = append(res, b)
+			continue
 		}
 
-		p.MissedStmt = p.TotalStmt - p.CoveredStmt
+		cur := &res[last]
 
-		p.Blocks = newBlocks
+		if before(cur.EndLine, cur.EndCol, b.EndLine, b.EndCol) {
+			cur.EndLine, cur.EndCol = b.EndLine, b.EndCol
+		}
 
-		profiles = append(profiles, p)
-	}
+		cur.NumStmt = max(cur.NumStmt, b.NumStmt)
 
-	sort.Sort(byFileName(profiles))
+		if mode == "set" {
+			cur.ExecCount |= b.ExecCount
+		} else {
+			cur.ExecCount += b.ExecCount
+		}
+	}
 
-	return profiles, nil
+	return res
 }
 
-// parseLine parses a line from a coverage file.
-func parseLine(l string) (fileName string, block ProfileBlock, err error) {
+// parseLine parses a line from a coverage file. The file name is a slice of
+// the line.
+func parseLine(l []byte) (fileName []byte, block ProfileBlock, err error) {
 	var (
 		b   ProfileBlock
 		end = len(l)
@@ -185,37 +430,37 @@ func parseLine(l string) (fileName string, block ProfileBlock, err error) {
 
 	b.ExecCount, end, err = seekBack(l, ' ', end, "ExecCount")
 	if err != nil {
-		return "", b, err
+		return nil, b, err
 	}
 
 	b.NumStmt, end, err = seekBack(l, ' ', end, "NumStmt")
 	if err != nil {
-		return "", b, err
+		return nil, b, err
 	}
 
 	b.EndCol, end, err = seekBack(l, '.', end, "EndCol")
 	if err != nil {
-		return "", b, err
+		return nil, b, err
 	}
 
 	b.EndLine, end, err = seekBack(l, ',', end, "EndLine")
 	if err != nil {
-		return "", b, err
+		return nil, b, err
 	}
 
 	b.StartCol, end, err = seekBack(l, '.', end, "StartCol")
 	if err != nil {
-		return "", b, err
+		return nil, b, err
 	}
 
 	b.StartLine, end, err = seekBack(l, ':', end, "StartLine")
 	if err != nil {
-		return "", b, err
+		return nil, b, err
 	}
 
 	fileName = l[:end]
-	if fileName == "" {
-		return "", b, errors.New("a FileName cannot be blank")
+	if len(fileName) == 0 {
+		return nil, b, errors.New("a FileName cannot be blank")
 	}
 
 	return fileName, b, nil
@@ -224,16 +469,16 @@ func parseLine(l string) (fileName string, block ProfileBlock, err error) {
 // seekBack searches backwards from end to find sep in l, then returns the
 // value between sep and end as an integer.
 // If seekBack fails, the returned error will reference `what`.
-func seekBack(l string, sep byte, end int, what string) (value, nextSep int, err error) {
+func seekBack(l []byte, sep byte, end int, what string) (value, nextSep int, err error) {
 	for cur := end - 1; cur >= 0; cur-- {
 		if l[cur] == sep {
-			i, err := strconv.Atoi(l[cur+1 : end])
+			i, err := atoi(l[cur+1 : end])
 			if err != nil {
 				return 0, 0, fmt.Errorf("couldn't parse %q: %v", what, err)
 			}
 
 			if i < 0 {
-				return 0, 0, fmt.Errorf("negative values are not allowed for %s, found %d", what, i)
+				return 0, 0, fmt.Errorf("%w for %s, found %d", errNegativeValue, what, i)
 			}
 
 			return i, cur, nil
@@ -242,3 +487,32 @@ func seekBack(l string, sep byte, end int, what string) (value, nextSep int, err
 
 	return 0, 0, fmt.Errorf("couldn't find a %s before %s", string(sep), what)
 }
+
+// atoi is strconv.Atoi for a byte slice; it only converts the slice to a
+// string to report an error, as the numbers of a line are small.
+func atoi(b []byte) (int, error) {
+	s := b
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+
+	if len(s) == 0 || len(s) > 18 {
+		return strconv.Atoi(string(b))
+	}
+
+	n := 0
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return strconv.Atoi(string(b))
+		}
+
+		n = n*10 + int(c-'0')
+	}
+
+	if b[0] == '-' {
+		n = -n
+	}
+
+	return n, nil
+}
@@ -0,0 +1,170 @@
+package coverage_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// largeProfile builds a count profile of files*blocks blocks, each reported
+// twice as by tests run for several packages.
+func largeProfile(files, blocks int) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("mode: count\n")
+
+	for run := range 2 {
+		for f := range files {
+			for b := range blocks {
+				_, _ = fmt.Fprintf(&buf, "example.com/large/pkg%d/file%d.go:%d.2,%d.16 %d %d\n",
+					f%50, f, b*3+1, b*3+2, b%4+1, (b+run)%3)
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
+var _ = Describe("Profile", func() {
+	Context("ParseProfiles", func() {
+		It("Should parse lines longer than the default scanner limit", func() {
+			name := "example.com/" + strings.Repeat("long/", 20_000) + "file.go"
+			data := "mode: set\n" + name + ":1.2,3.4 2 1\n"
+
+			profiles, err := coverage.ParseProfilesFromReader(strings.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profiles).To(HaveLen(1))
+			Expect(profiles[0].FileName).To(Equal(name))
+			Expect(profiles[0].CoveredStmt).To(Equal(2))
+		})
+
+		It("Should return the same profiles with parallel workers", func() {
+			data := largeProfile(120, 40)
+
+			sequential, err := coverage.ParseProfilesFromReader(bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+
+			parallel, _, err := coverage.ParseProfiles(bytes.NewReader(data), coverage.ParseOptions{Workers: 8})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(parallel).To(Equal(sequential))
+			Expect(sequential).To(HaveLen(120))
+			Expect(sequential[0].Blocks).To(HaveLen(40), "the duplicated blocks must be merged")
+		})
+
+		It("Should match the profiles read from a file", func() {
+			data, err := os.ReadFile("testdata/01-new-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			expected, err := coverage.NewProfilesFromFile("testdata/01-new-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			profiles, diags, err := coverage.ParseProfiles(bytes.NewReader(data), coverage.ParseOptions{Workers: 4})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diags).To(BeEmpty())
+			Expect(profiles).To(Equal(expected))
+		})
+
+		It("Should report the first invalid file whatever the workers", func() {
+			data := "mode: set\nb.go:1.1,2.2 1 1\nb.go:1.1,2.2 2 1\na.go:1.1,2.2 1 1\na.go:1.1,2.2 3 1\n"
+
+			_, _, err := coverage.ParseProfiles(strings.NewReader(data), coverage.ParseOptions{Workers: 4})
+			Expect(err).To(MatchError("line 5: a.go: inconsistent NumStmt: changed from 1 to 3"))
+		})
+
+		It("Should reject a malformed line with a ParseError", func() {
+			_, err := coverage.ParseProfilesFromReader(strings.NewReader("mode: set\na.go:1.1,2.x 1 1\n"))
+
+			var perr *coverage.ParseError
+			Expect(errors.As(err, &perr)).To(BeTrue())
+			Expect(perr.Diagnostic).To(Equal(coverage.Diagnostic{
+				Line:     2,
+				FileName: "a.go",
+				Reason:   coverage.ReasonFormat,
+				Message:  `couldn't parse "EndCol": strconv.Atoi: parsing "x": invalid syntax`,
+			}))
+		})
+
+		It("Should accept concatenated profiles of the same mode", func() {
+			data := "mode: set\na.go:1.1,2.2 1 0\nmode: set\na.go:1.1,2.2 1 1\nb.go:1.1,2.2 2 1\n"
+
+			profiles, err := coverage.ParseProfilesFromReader(strings.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profiles).To(HaveLen(2))
+			Expect(profiles[0].CoveredStmt).To(Equal(1))
+		})
+
+		When("lenient", func() {
+			It("Should skip the invalid lines and report them", func() {
+				data := strings.Join([]string{
+					"mode: set",
+					"a.go:1.1,2.2 1 1",
+					"a.go:3.1,4.2 1 -1",
+					"garbage",
+					"a.go:1.1,2.2 2 1",
+					"mode: count",
+					"b.go:1.1,2.2 1 5",
+					"mode: set",
+					"b.go:3.1,4.2 1 1",
+				}, "\n")
+
+				profiles, diags, err := coverage.ParseProfiles(strings.NewReader(data), coverage.ParseOptions{Lenient: true})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(profiles).To(HaveLen(2))
+				Expect(profiles[0].Blocks).To(HaveLen(1))
+				Expect(profiles[0].CoveredStmt).To(Equal(1))
+				Expect(profiles[1].Blocks).To(Equal([]coverage.ProfileBlock{
+					{StartLine: 3, StartCol: 1, EndLine: 4, EndCol: 2, NumStmt: 1, ExecCount: 1},
+				}), "the blocks of another mode must be skipped")
+
+				Expect(diags).To(HaveLen(4))
+				Expect(diags[0].String()).To(Equal("line 3: a.go: negative value: negative values are not allowed for ExecCount, found -1"))
+				Expect(diags[1].String()).To(Equal(`line 4: bad format: couldn't find a   before ExecCount`))
+				Expect(diags[2].String()).To(Equal("line 5: a.go: inconsistent NumStmt: changed from 1 to 2"))
+				Expect(diags[3].String()).To(Equal(`line 6: mode mismatch: "count" differs from "set"`))
+			})
+
+			It("Should name the profile in the diagnostics", func() {
+				cov, err := coverage.NewCoverageFromFilesWithOptions(coverage.ParseOptions{Lenient: true}, "testdata/05-invalid-coverage.txt")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cov.TotalStmt).To(Equal(3))
+				Expect(cov.Diagnostics).To(HaveLen(1))
+				Expect(cov.Diagnostics[0].String()).To(HavePrefix("testdata/05-invalid-coverage.txt:3: example.com/app/main.go: bad format:"))
+
+				_, err = coverage.NewCoverageFromFiles("testdata/05-invalid-coverage.txt")
+				Expect(err).To(MatchError(cov.Diagnostics[0].String()))
+			})
+		})
+	})
+})
+
+func benchmarkParseProfiles(b *testing.B, opts coverage.ParseOptions) {
+	data := largeProfile(500, 200)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if _, _, err := coverage.ParseProfiles(bytes.NewReader(data), opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseProfiles(b *testing.B) {
+	benchmarkParseProfiles(b, coverage.ParseOptions{})
+}
+
+func BenchmarkParseProfilesParallel(b *testing.B) {
+	benchmarkParseProfiles(b, coverage.ParseOptions{Workers: 4})
+}
@@ -0,0 +1,95 @@
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// StdinPath is the file name of the profile read from the standard input.
+const StdinPath = "-"
+
+// stdinName names the standard input in the diagnostics.
+const stdinName = "stdin"
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompress returns a reader of the decompressed content of rd, when it is
+// compressed with gzip or zstd, whatever the name it is read from. The
+// returned function releases the decompressor.
+func decompress(rd io.Reader) (io.Reader, func(), error) {
+	br := bufio.NewReader(rd)
+
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return zr, func() { _ = zr.Close() }, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return zr, zr.Close, nil
+	default:
+		return br, func() {}, nil
+	}
+}
+
+// parseProfilesFromFile parses the profile data in the specified file, or
+// the standard input for StdinPath, naming the file in the diagnostics.
+func parseProfilesFromFile(fileName string, opts ParseOptions) ([]Profile, []Diagnostic, error) {
+	if fileName == StdinPath {
+		return parseNamedProfiles(stdinName, os.Stdin, opts)
+	}
+
+	pf, err := os.Open(filepath.Clean(fileName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		_ = pf.Close()
+	}()
+
+	return parseNamedProfiles(fileName, pf, opts)
+}
+
+// parseProfilesFromFS parses the profile data in the named file of fsys.
+func parseProfilesFromFS(fsys fs.FS, name string, opts ParseOptions) ([]Profile, []Diagnostic, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return parseNamedProfiles(name, f, opts)
+}
+
+func parseNamedProfiles(name string, rd io.Reader, opts ParseOptions) ([]Profile, []Diagnostic, error) {
+	profiles, diags, err := ParseProfiles(rd, opts)
+	diags, err = withProfile(name, diags, err)
+
+	return profiles, diags, err
+}
@@ -0,0 +1,91 @@
+package coverage_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"testing/fstest"
+
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Reader", func() {
+	var (
+		data     []byte
+		expected *coverage.Coverage
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		data, err = os.ReadFile("testdata/01-new-coverage.txt")
+		Expect(err).NotTo(HaveOccurred())
+
+		expected, err = coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("NewCoverageFromReader", func() {
+		It("Should decompress a gzip profile", func() {
+			var buf bytes.Buffer
+
+			zw := gzip.NewWriter(&buf)
+			_, err := zw.Write(data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zw.Close()).To(Succeed())
+
+			Expect(coverage.NewCoverageFromReader(&buf, coverage.ParseOptions{})).To(Equal(expected))
+		})
+
+		It("Should decompress a zstd profile", func() {
+			var buf bytes.Buffer
+
+			zw, err := zstd.NewWriter(&buf)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = zw.Write(data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zw.Close()).To(Succeed())
+
+			Expect(coverage.NewCoverageFromReader(&buf, coverage.ParseOptions{})).To(Equal(expected))
+		})
+
+		It("Should read an empty profile", func() {
+			cov, err := coverage.NewCoverageFromReader(new(bytes.Buffer), coverage.ParseOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cov.Files).To(BeEmpty())
+		})
+	})
+
+	Context("NewCoverageFromFS", func() {
+		It("Should read the profiles of a zip archive", func() {
+			var buf bytes.Buffer
+
+			zw := zip.NewWriter(&buf)
+			w, err := zw.Create("coverage/new.out")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = w.Write(data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zw.Close()).To(Succeed())
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(coverage.NewCoverageFromFS(archive, coverage.ParseOptions{}, "coverage/new.out")).To(Equal(expected))
+		})
+
+		It("Should name the profile in the errors", func() {
+			fsys := fstest.MapFS{"bad.out": {Data: []byte("mode: set\na.go:1.1,2.2 1\n")}}
+
+			_, err := coverage.NewCoverageFromFS(fsys, coverage.ParseOptions{}, "bad.out")
+			Expect(err).To(MatchError(HavePrefix("bad.out:2: a.go: bad format:")))
+
+			_, err = coverage.NewCoverageFromFS(fsys, coverage.ParseOptions{}, "missing.out")
+			Expect(err).To(MatchError(ContainSubstring("missing.out")))
+		})
+	})
+})
@@ -0,0 +1,78 @@
+package coverage
+
+// blockShape describes a block regardless of its position in the file, so
+// that blocks shifted by added or removed lines still match.
+type blockShape struct {
+	height, startCol, endCol, numStmt int
+}
+
+// Similarity compares the block structure of two profiles. It is the share of
+// blocks with the same shape, i.e. statements, height and columns, found in
+// both profiles, from 0 to 1. Profiles without blocks are not similar.
+func Similarity(a, b Profile) float64 {
+	if len(a.Blocks) == 0 || len(b.Blocks) == 0 {
+		return 0
+	}
+
+	shapes := make(map[blockShape]int, len(a.Blocks))
+	for _, block := range a.Blocks {
+		shapes[shapeOf(block)]++
+	}
+
+	common := 0
+
+	for _, block := range b.Blocks {
+		if shape := shapeOf(block); shapes[shape] > 0 {
+			shapes[shape]--
+			common++
+		}
+	}
+
+	return float64(2*common) / float64(len(a.Blocks)+len(b.Blocks))
+}
+
+func shapeOf(b ProfileBlock) blockShape {
+	return blockShape{
+		height:   b.EndLine - b.StartLine,
+		startCol: b.StartCol,
+		endCol:   b.EndCol,
+		numStmt:  b.NumStmt,
+	}
+}
+
+// Rename returns a copy of the coverage with the files renamed, renames being
+// keyed by the new name with the old name as value.
+func (c *Coverage) Rename(renames map[string]string) *Coverage {
+	oldToNew := make(map[string]string, len(renames))
+	for newName, oldName := range renames {
+		oldToNew[oldName] = newName
+	}
+
+	profiles := make([]Profile, 0, len(c.Files))
+
+	for name, p := range c.Files {
+		if newName, ok := oldToNew[name]; ok {
+			p.FileName = newName
+		}
+
+		profiles = append(profiles, p)
+	}
+
+	res := NewCoverage(profiles)
+	res.Diagnostics = c.Diagnostics
+
+	if c.ExcludedFiles != nil {
+		res.ExcludedFiles = make(map[string]ExcludedFile, len(c.ExcludedFiles))
+	}
+
+	for name, f := range c.ExcludedFiles {
+		if newName, ok := oldToNew[name]; ok {
+			name = newName
+		}
+
+		res.ExcludedFiles[name] = f
+		res.ExcludedStmt += f.Stmt
+	}
+
+	return res
+}
@@ -0,0 +1,67 @@
+package coverage_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Rename", func() {
+	blocks := []coverage.ProfileBlock{
+		{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 2, NumStmt: 1},
+		{StartLine: 7, StartCol: 30, EndLine: 12, EndCol: 2, NumStmt: 3},
+		{StartLine: 8, StartCol: 10, EndLine: 10, EndCol: 3, NumStmt: 1},
+	}
+
+	shifted := func(lines int) []coverage.ProfileBlock {
+		res := make([]coverage.ProfileBlock, len(blocks))
+		for i, b := range blocks {
+			b.StartLine += lines
+			b.EndLine += lines
+			res[i] = b
+		}
+
+		return res
+	}
+
+	DescribeTable("Similarity",
+		func(a, b []coverage.ProfileBlock, expected float64) {
+			Expect(coverage.Similarity(coverage.Profile{Blocks: a}, coverage.Profile{Blocks: b})).To(BeNumerically("~", expected, 0.001))
+		},
+		Entry("same blocks", blocks, blocks, 1.0),
+		Entry("blocks shifted by new lines", blocks, shifted(4), 1.0),
+		Entry("one block changed", blocks, append(shifted(0)[:2], coverage.ProfileBlock{StartLine: 8, EndLine: 9, NumStmt: 2}), 0.667),
+		Entry("added block", blocks, append(shifted(0), coverage.ProfileBlock{StartLine: 14, EndLine: 15, NumStmt: 1}), 0.857),
+		Entry("no blocks", nil, nil, 0.0),
+	)
+
+	It("Should rename the files", func() {
+		cov := coverage.NewCoverage([]coverage.Profile{
+			{FileName: "example.com/mod/a/file.go", TotalStmt: 5, CoveredStmt: 4, MissedStmt: 1},
+			{FileName: "example.com/mod/a/other.go", TotalStmt: 2, CoveredStmt: 2},
+		})
+
+		renamed := cov.Rename(map[string]string{"example.com/mod/b/file.go": "example.com/mod/a/file.go"})
+		Expect(renamed.Files).To(HaveKey("example.com/mod/b/file.go"))
+		Expect(renamed.Files).NotTo(HaveKey("example.com/mod/a/file.go"))
+		Expect(renamed.Files["example.com/mod/b/file.go"].FileName).To(Equal("example.com/mod/b/file.go"))
+		Expect(renamed.TotalStmt).To(Equal(7))
+
+		Expect(cov.Files).To(HaveKey("example.com/mod/a/file.go"), "the coverage must not be modified")
+	})
+
+	It("Should rename the excluded files", func() {
+		cov := coverage.NewCoverage(nil)
+		cov.ExcludedFiles = map[string]coverage.ExcludedFile{
+			"example.com/mod/a/gen.go": {Reason: coverage.ReasonGenerated, Stmt: 3},
+		}
+
+		renamed := cov.Rename(map[string]string{"example.com/mod/b/gen.go": "example.com/mod/a/gen.go"})
+		Expect(renamed.ExcludedFiles).To(Equal(map[string]coverage.ExcludedFile{
+			"example.com/mod/b/gen.go": {Reason: coverage.ReasonGenerated, Stmt: 3},
+		}))
+		Expect(renamed.ExcludedStmt).To(Equal(3))
+		Expect(cov.ExcludedFiles).To(HaveKey("example.com/mod/a/gen.go"), "the coverage must not be modified")
+	})
+})
@@ -9,7 +9,20 @@ func (b blocksByStart) Less(i, j int) bool {
 	return bi.StartLine < bj.StartLine || bi.StartLine == bj.StartLine && bi.StartCol < bj.StartCol
 }
 
-type byFileName []Profile
+// numberedBlocks sorts blocks along with their line in the profile.
+type numberedBlocks struct {
+	blocks []ProfileBlock
+	lines  []int
+}
+
+func (b numberedBlocks) Len() int           { return len(b.blocks) }
+func (b numberedBlocks) Less(i, j int) bool { return blocksByStart(b.blocks).Less(i, j) }
+func (b numberedBlocks) Swap(i, j int) {
+	b.blocks[i], b.blocks[j] = b.blocks[j], b.blocks[i]
+	b.lines[i], b.lines[j] = b.lines[j], b.lines[i]
+}
+
+type byFileName []*parsedProfile
 
 func (p byFileName) Len() int           { return len(p) }
 func (p byFileName) Less(i, j int) bool { return p[i].FileName < p[j].FileName }
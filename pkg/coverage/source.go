@@ -0,0 +1,354 @@
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	ReasonGenerated = "generated"
+	ReasonDirective = "directive"
+
+	// IgnoreDirective excludes the file, function, statement or block it is
+	// attached to from the coverage.
+	IgnoreDirective = "//coverage:ignore"
+)
+
+var ErrModuleNotFound = errors.New("no go.mod found")
+
+// ExcludedFile describes a file dropped from the coverage because of its
+// source.
+type ExcludedFile struct {
+	Reason string
+	Stmt   int
+}
+
+// Source locates the source files of the profiles of a module on disk.
+type Source struct {
+	Dir        string
+	ModulePath string
+
+	// Generated drops the files holding the standard
+	// "// Code generated ... DO NOT EDIT." header.
+	Generated bool
+	// Directives removes the blocks annotated with IgnoreDirective.
+	Directives bool
+
+	files map[string]*sourceFile
+}
+
+type sourceFile struct {
+	// reason is set when the whole file is excluded.
+	reason  string
+	ignored []posRange
+}
+
+type posRange struct {
+	start, end token.Position
+}
+
+func (r posRange) contains(b ProfileBlock) bool {
+	return before(r.start.Line, r.start.Column, b.StartLine, b.StartCol) &&
+		before(b.EndLine, b.EndCol, r.end.Line, r.end.Column)
+}
+
+func before(line1, col1, line2, col2 int) bool {
+	return line1 < line2 || line1 == line2 && col1 <= col2
+}
+
+// NewSource returns the Source of the module with the given path checked out
+// in dir.
+func NewSource(dir, modulePath string) *Source {
+	return &Source{Dir: dir, ModulePath: modulePath, files: make(map[string]*sourceFile)}
+}
+
+// FindSource returns the Source of the module holding dir, looking for the
+// go.mod file in dir and its parents.
+func FindSource(dir string) (*Source, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			modulePath := modulePathOf(data)
+			if modulePath == "" {
+				return nil, fmt.Errorf("missing module directive in %s", filepath.Join(dir, "go.mod"))
+			}
+
+			return NewSource(dir, modulePath), nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrModuleNotFound
+		}
+
+		dir = parent
+	}
+}
+
+func modulePathOf(goMod []byte) string {
+	s := bufio.NewScanner(bytes.NewReader(goMod))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			rest = strings.TrimSpace(rest)
+			if unquoted, err := strconv.Unquote(rest); err == nil {
+				return unquoted
+			}
+
+			return rest
+		}
+	}
+
+	return ""
+}
+
+// Path returns the location on disk of a profile file name, if the file
+// belongs to the module.
+func (s *Source) Path(fileName string) (string, bool) {
+	dir, ok := s.PackageDir(PackagePath(fileName))
+	if !ok {
+		return "", false
+	}
+
+	return filepath.Join(dir, path.Base(fileName)), true
+}
+
+// PackageDir returns the directory of a package of the module.
+func (s *Source) PackageDir(importPath string) (string, bool) {
+	if importPath == s.ModulePath {
+		return s.Dir, true
+	}
+
+	rel, ok := strings.CutPrefix(importPath, s.ModulePath+"/")
+	if !ok {
+		return "", false
+	}
+
+	return filepath.Join(s.Dir, filepath.FromSlash(rel)), true
+}
+
+// Apply returns a copy of cov without the generated files and the blocks
+// annotated with IgnoreDirective. Files which can't be read are kept as is,
+// so that the Sources of several modules can be applied in turn.
+func (s *Source) Apply(cov *Coverage) *Coverage {
+	return s.apply(cov, nil)
+}
+
+// ApplyBaseline is like Apply for the coverage of a previous revision, whose
+// source is not on disk. Generated files are dropped by name, while
+// directives are only applied to files whose blocks are unchanged in
+// current, as their positions can't be trusted otherwise.
+func (s *Source) ApplyBaseline(baseline, current *Coverage) *Coverage {
+	return s.apply(baseline, current)
+}
+
+func (s *Source) apply(cov, current *Coverage) *Coverage {
+	var (
+		profiles = make([]Profile, 0, len(cov.Files))
+		excluded = make(map[string]ExcludedFile, len(cov.ExcludedFiles))
+	)
+
+	for name, f := range cov.ExcludedFiles {
+		excluded[name] = f
+	}
+
+	for name, p := range cov.Files {
+		file := s.file(name)
+
+		if file.reason != "" {
+			excluded[name] = ExcludedFile{Reason: file.reason, Stmt: p.TotalStmt + p.ExcludedStmt}
+			continue
+		}
+
+		if len(file.ignored) > 0 && (current == nil || sameBlocks(p, current.Files[name])) {
+			p = p.without(file.ignored)
+		}
+
+		profiles = append(profiles, p)
+	}
+
+	res := NewCoverage(profiles)
+	res.ExcludedFiles = excluded
+	res.Diagnostics = cov.Diagnostics
+
+	for _, f := range excluded {
+		res.ExcludedStmt += f.Stmt
+	}
+
+	return res
+}
+
+func sameBlocks(a, b Profile) bool {
+	if len(a.Blocks) != len(b.Blocks) {
+		return false
+	}
+
+	for i := range a.Blocks {
+		if !a.Blocks[i].Equal(b.Blocks[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// without returns a copy of the profile without the blocks inside of ranges.
+func (p Profile) without(ranges []posRange) Profile {
+	kept := make([]ProfileBlock, 0, len(p.Blocks))
+
+	for _, b := range p.Blocks {
+		ignored := false
+
+		for _, r := range ranges {
+			if r.contains(b) {
+				ignored = true
+				break
+			}
+		}
+
+		if ignored {
+			p.ExcludedStmt += b.NumStmt
+			continue
+		}
+
+		kept = append(kept, b)
+	}
+
+	p.Blocks = kept
+	p.count()
+
+	return p
+}
+
+func (s *Source) file(name string) *sourceFile {
+	if f, ok := s.files[name]; ok {
+		return f
+	}
+
+	f := &sourceFile{}
+	s.files[name] = f
+
+	path, ok := s.Path(name)
+	if !ok {
+		return f
+	}
+
+	src, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return f
+	}
+
+	fset := token.NewFileSet()
+
+	parsed, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return f
+	}
+
+	if s.Generated && ast.IsGenerated(parsed) {
+		f.reason = ReasonGenerated
+		return f
+	}
+
+	if s.Directives {
+		f.reason, f.ignored = ignoredRanges(fset, parsed, src)
+	}
+
+	return f
+}
+
+// ignoredRanges resolves the IgnoreDirective comments of a file. A directive
+// before the package clause ignores the file. A directive on its own line
+// ignores the declaration or statement following it, while a trailing
+// directive ignores the block opened on its line, e.g. the body of
+// "} else { //coverage:ignore", or else the statement on its line.
+func ignoredRanges(fset *token.FileSet, file *ast.File, src []byte) (reason string, ranges []posRange) {
+	lines := bytes.Split(src, []byte("\n"))
+
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if !isIgnoreDirective(c.Text) {
+				continue
+			}
+
+			pos := fset.Position(c.Pos())
+			if c.Pos() < file.Package {
+				return ReasonDirective, nil
+			}
+
+			trailing := len(bytes.TrimSpace(lines[pos.Line-1][:pos.Column-1])) > 0
+			target := fset.Position(group.End()).Line + 1
+
+			if trailing {
+				target = pos.Line
+			}
+
+			if node := nodeAt(fset, file, target, trailing, pos.Column); node != nil {
+				ranges = append(ranges, posRange{start: fset.Position(node.Pos()), end: fset.Position(node.End())})
+			}
+		}
+	}
+
+	return "", ranges
+}
+
+func isIgnoreDirective(text string) bool {
+	rest, ok := strings.CutPrefix(text, IgnoreDirective)
+	return ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// nodeAt returns the outermost declaration or statement starting on line.
+// For a trailing directive, the block opened on the line is preferred.
+func nodeAt(fset *token.FileSet, file *ast.File, line int, trailing bool, column int) ast.Node {
+	var outermost, block ast.Node
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+
+		switch n.(type) {
+		case ast.Stmt, ast.Decl:
+		default:
+			return true
+		}
+
+		pos := fset.Position(n.Pos())
+		if pos.Line > line {
+			return false
+		}
+
+		if pos.Line == line && (!trailing || pos.Column < column) {
+			if outermost == nil {
+				outermost = n
+			}
+
+			if _, ok := n.(*ast.BlockStmt); ok {
+				block = n
+			}
+		}
+
+		return fset.Position(n.End()).Line >= line
+	})
+
+	if trailing && block != nil {
+		return block
+	}
+
+	return outermost
+}
@@ -0,0 +1,75 @@
+package coverage_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Source", func() {
+	var (
+		src *coverage.Source
+		cov *coverage.Coverage
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		src, err = coverage.FindSource("testdata/source/calc")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(src.ModulePath).To(Equal("example.com/source"))
+
+		cov, err = coverage.NewCoverageFromFile("testdata/03-source-coverage.txt")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should exclude generated files and ignored code", func() {
+		src.Generated, src.Directives = true, true
+
+		res := src.Apply(cov)
+		Expect(res.Files).To(HaveLen(1))
+		Expect(res.ExcludedFiles).To(Equal(map[string]coverage.ExcludedFile{
+			"example.com/source/calc/gen.go":     {Reason: coverage.ReasonGenerated, Stmt: 1},
+			"example.com/source/calc/ignored.go": {Reason: coverage.ReasonDirective, Stmt: 1},
+		}))
+
+		profile := res.Files["example.com/source/calc/calc.go"]
+		Expect(profile.TotalStmt).To(Equal(7))
+		Expect(profile.CoveredStmt).To(Equal(6))
+		Expect(profile.MissedStmt).To(Equal(1))
+		Expect(profile.ExcludedStmt).To(Equal(3))
+
+		Expect(res.TotalStmt).To(Equal(7))
+		Expect(res.ExcludedStmt).To(Equal(5))
+
+		Expect(cov.TotalStmt).To(Equal(12), "the input must not be modified")
+	})
+
+	It("Should only exclude generated files", func() {
+		src.Generated = true
+
+		res := src.Apply(cov)
+		Expect(res.Files).To(HaveLen(2))
+		Expect(res.TotalStmt).To(Equal(11))
+		Expect(res.ExcludedStmt).To(Equal(1))
+	})
+
+	When("applied to a baseline", func() {
+		It("Should only honor directives of unchanged files", func() {
+			src.Generated, src.Directives = true, true
+
+			baseline := coverage.NewCoverage([]coverage.Profile{
+				{FileName: "example.com/source/calc/calc.go", Blocks: []coverage.ProfileBlock{
+					{StartLine: 17, StartCol: 3, EndLine: 18, EndCol: 1, NumStmt: 5},
+				}, TotalStmt: 5},
+				{FileName: "example.com/source/calc/gen.go", TotalStmt: 2},
+			})
+
+			res := src.ApplyBaseline(baseline, cov)
+			Expect(res.Files).To(HaveKey("example.com/source/calc/calc.go"))
+			Expect(res.TotalStmt).To(Equal(5))
+			Expect(res.ExcludedStmt).To(Equal(2))
+		})
+	})
+})
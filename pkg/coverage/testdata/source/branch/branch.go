@@ -0,0 +1,56 @@
+package branch
+
+// Clamp limits v to [lo, hi].
+func Clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+
+	if v > hi {
+		return hi
+	}
+
+	return v
+}
+
+// Abs returns the absolute value of v.
+func Abs(v int) int {
+	if v < 0 {
+		v = -v
+	}
+
+	return v
+}
+
+// Kind describes v.
+func Kind(v int) string {
+	switch {
+	case v < 0:
+		return "negative"
+	case v == 0:
+		return "zero"
+	}
+
+	return "positive"
+}
+
+// Parity describes v.
+func Parity(v int) string {
+	if v%2 == 0 {
+		return "even"
+	} else if v%2 == 1 {
+		return "odd"
+	} else {
+		return "negative odd"
+	}
+}
+
+// Recv reads c without blocking.
+func Recv(c chan int) (int, bool) {
+	select {
+	case v := <-c:
+		return v, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,15 @@
+package branch
+
+import "testing"
+
+func TestBranch(t *testing.T) {
+	Clamp(5, 0, 10)
+	Clamp(-1, 0, 10)
+	Abs(3)
+	Abs(-3)
+	Kind(-1)
+	Kind(1)
+	Parity(2)
+	Parity(3)
+	Recv(make(chan int))
+}
@@ -0,0 +1,7 @@
+//coverage:ignore whole file
+
+package calc
+
+func Ignored() int {
+	return 1
+}
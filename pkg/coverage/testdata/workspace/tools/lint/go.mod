@@ -0,0 +1,3 @@
+module example.com/mono/tools/lint
+
+go 1.23
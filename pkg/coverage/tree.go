@@ -0,0 +1,128 @@
+package coverage
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	KindModule    = "module"
+	KindDirectory = "directory"
+	KindPackage   = "package"
+	KindFile      = "file"
+)
+
+// Tree aggregates the coverage hierarchically: the root is the module,
+// followed by directories, packages (directories holding files) and files.
+type Tree struct {
+	Name        string
+	Path        string
+	Kind        string
+	TotalStmt   int
+	CoveredStmt int
+	MissedStmt  int
+	Children    []*Tree `json:",omitempty"`
+}
+
+func (t *Tree) Percent() float64 {
+	if t.TotalStmt == 0 {
+		return 0
+	}
+
+	return float64(t.CoveredStmt) / float64(t.TotalStmt) * 100
+}
+
+// Walk calls fn for the tree and all its descendants, parents first.
+func (t *Tree) Walk(fn func(node *Tree)) {
+	fn(t)
+
+	for _, child := range t.Children {
+		child.Walk(fn)
+	}
+}
+
+// Tree builds the coverage tree below root. An empty root selects the
+// longest package path shared by all files. Files outside of root are
+// ignored.
+func (c *Coverage) Tree(root string) *Tree {
+	if root == "" {
+		root = c.commonPackage()
+	}
+
+	var (
+		tree  = &Tree{Name: root, Path: root, Kind: KindModule}
+		nodes = map[string]*Tree{root: tree}
+	)
+
+	for name, p := range c.Files {
+		rel, ok := strings.CutPrefix(name, root+"/")
+		if root == "" {
+			rel, ok = name, true
+		}
+
+		if !ok {
+			continue
+		}
+
+		node, parts := tree, strings.Split(rel, "/")
+
+		tree.add(p)
+
+		for i, part := range parts {
+			nodePath := strings.TrimPrefix(root+"/"+strings.Join(parts[:i+1], "/"), "/")
+
+			child, ok := nodes[nodePath]
+			if !ok {
+				child = &Tree{Name: part, Path: nodePath, Kind: KindDirectory}
+				if i == len(parts)-1 {
+					child.Kind = KindFile
+				}
+
+				nodes[nodePath] = child
+				node.Children = append(node.Children, child)
+			}
+
+			if child.Kind == KindFile && node.Kind == KindDirectory {
+				node.Kind = KindPackage
+			}
+
+			child.add(p)
+			node = child
+		}
+	}
+
+	tree.Walk(func(node *Tree) {
+		sort.Slice(node.Children, func(i, j int) bool { return node.Children[i].Name < node.Children[j].Name })
+	})
+
+	return tree
+}
+
+func (t *Tree) add(p Profile) {
+	t.TotalStmt += p.TotalStmt
+	t.CoveredStmt += p.CoveredStmt
+	t.MissedStmt += p.MissedStmt
+}
+
+func (c *Coverage) commonPackage() string {
+	var common []string
+
+	first := true
+
+	for name := range c.Files {
+		parts := strings.Split(PackagePath(name), "/")
+		if first {
+			common, first = parts, false
+			continue
+		}
+
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+
+		common = common[:n]
+	}
+
+	return strings.Join(common, "/")
+}
@@ -0,0 +1,44 @@
+package coverage_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Tree", func() {
+	cov := coverage.NewCoverage([]coverage.Profile{
+		{FileName: "example.com/mod/main.go", TotalStmt: 2, CoveredStmt: 2},
+		{FileName: "example.com/mod/internal/a/a.go", TotalStmt: 10, CoveredStmt: 5, MissedStmt: 5},
+		{FileName: "example.com/mod/internal/a/b/b.go", TotalStmt: 10, CoveredStmt: 10},
+	})
+
+	It("Should aggregate each level", func() {
+		tree := cov.Tree("")
+		Expect(tree.Path).To(Equal("example.com/mod"))
+		Expect(tree.Kind).To(Equal(coverage.KindModule))
+		Expect(tree.TotalStmt).To(Equal(22))
+		Expect(tree.Children).To(HaveLen(2))
+
+		internal := tree.Children[0]
+		Expect(internal.Name).To(Equal("internal"))
+		Expect(internal.Kind).To(Equal(coverage.KindDirectory))
+		Expect(internal.Percent()).To(BeNumerically("==", 75))
+
+		pkg := internal.Children[0]
+		Expect(pkg.Path).To(Equal("example.com/mod/internal/a"))
+		Expect(pkg.Kind).To(Equal(coverage.KindPackage))
+		Expect(pkg.Children).To(HaveLen(2))
+		Expect(pkg.Children[0].Kind).To(Equal(coverage.KindFile))
+		Expect(pkg.Children[0].Name).To(Equal("a.go"))
+
+		Expect(tree.Children[1].Name).To(Equal("main.go"))
+	})
+
+	It("Should ignore files outside of the root", func() {
+		tree := cov.Tree("example.com/mod/internal/a/b")
+		Expect(tree.TotalStmt).To(Equal(10))
+		Expect(tree.Children).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,130 @@
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrWorkspaceNotFound = errors.New("no go.work found")
+
+// Module is a module of a workspace.
+type Module struct {
+	Path string
+	// Dir is the directory of the module, relative to the workspace.
+	Dir string
+}
+
+// Workspace lists the modules used by a go.work file.
+type Workspace struct {
+	Dir     string
+	Modules []Module
+}
+
+// FindWorkspace returns the Workspace holding dir, looking for the go.work
+// file in dir and its parents.
+func FindWorkspace(dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.work"))
+		if err == nil {
+			return readWorkspace(dir, data)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrWorkspaceNotFound
+		}
+
+		dir = parent
+	}
+}
+
+func readWorkspace(dir string, goWork []byte) (*Workspace, error) {
+	ws := &Workspace{Dir: dir}
+
+	for _, use := range workspaceUses(goWork) {
+		goMod := filepath.Join(dir, filepath.FromSlash(use), "go.mod")
+
+		data, err := os.ReadFile(filepath.Clean(goMod))
+		if err != nil {
+			return nil, fmt.Errorf("failed reading module of workspace: %w", err)
+		}
+
+		modulePath := modulePathOf(data)
+		if modulePath == "" {
+			return nil, fmt.Errorf("missing module directive in %s", goMod)
+		}
+
+		ws.Modules = append(ws.Modules, Module{Path: modulePath, Dir: filepath.ToSlash(filepath.Clean(use))})
+	}
+
+	return ws, nil
+}
+
+// workspaceUses returns the directories of the use directives of a go.work
+// file, in both the single line and the block form.
+func workspaceUses(goWork []byte) []string {
+	var (
+		res     []string
+		inBlock bool
+		s       = bufio.NewScanner(bytes.NewReader(goWork))
+	)
+
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+
+		line = strings.TrimSpace(line)
+
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case inBlock:
+		case line == "use (":
+			inBlock = true
+			continue
+		default:
+			rest, ok := strings.CutPrefix(line, "use")
+			if !ok || rest == "" || rest[0] != ' ' && rest[0] != '\t' {
+				continue
+			}
+
+			line = strings.TrimSpace(rest)
+		}
+
+		if line == "" {
+			continue
+		}
+
+		if unquoted, err := strconv.Unquote(line); err == nil {
+			line = unquoted
+		}
+
+		res = append(res, line)
+	}
+
+	return res
+}
+
+// Sources returns the Source of every module of the workspace.
+func (w *Workspace) Sources() []*Source {
+	res := make([]*Source, len(w.Modules))
+	for i, m := range w.Modules {
+		res[i] = NewSource(filepath.Join(w.Dir, filepath.FromSlash(m.Dir)), m.Path)
+	}
+
+	return res
+}
@@ -0,0 +1,36 @@
+package coverage_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Workspace", func() {
+	It("Should read the modules of the go.work file", func() {
+		ws, err := coverage.FindWorkspace("testdata/workspace/tools")
+		Expect(err).NotTo(HaveOccurred())
+
+		abs, err := filepath.Abs("testdata/workspace")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ws.Dir).To(Equal(abs))
+
+		Expect(ws.Modules).To(Equal([]coverage.Module{
+			{Path: "example.com/mono/api", Dir: "api"},
+			{Path: "example.com/mono/tools/lint", Dir: "tools/lint"},
+		}))
+
+		srcs := ws.Sources()
+		Expect(srcs).To(HaveLen(2))
+		Expect(srcs[1].Dir).To(Equal(filepath.Join(abs, "tools", "lint")))
+		Expect(srcs[1].ModulePath).To(Equal("example.com/mono/tools/lint"))
+	})
+
+	It("Should fail outside of a workspace", func() {
+		_, err := coverage.FindWorkspace("/")
+		Expect(err).To(MatchError(coverage.ErrWorkspaceNotFound))
+	})
+})
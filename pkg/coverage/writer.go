@@ -0,0 +1,61 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DefaultMode is the mode written for profiles without blocks.
+const DefaultMode = "set"
+
+// WriteProfiles writes the profiles in the text format of
+// go test -coverprofile. The files are sorted by name and their blocks by
+// position, so that equal profiles are written identically. All profiles
+// must share the same mode.
+func WriteProfiles(w io.Writer, profiles []Profile) error {
+	mode := DefaultMode
+	if len(profiles) > 0 {
+		mode = profiles[0].Mode
+	}
+
+	sorted := make([]*Profile, len(profiles))
+
+	for i := range profiles {
+		if profiles[i].Mode != mode {
+			return fmt.Errorf("%s: mode %q differs from %q", profiles[i].FileName, profiles[i].Mode, mode)
+		}
+
+		sorted[i] = &profiles[i]
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].FileName < sorted[j].FileName })
+
+	bw := bufio.NewWriter(w)
+
+	_, _ = fmt.Fprintf(bw, "mode: %s\n", mode)
+
+	for _, p := range sorted {
+		blocks := append([]ProfileBlock(nil), p.Blocks...)
+		sort.Stable(blocksByStart(blocks))
+
+		for _, b := range blocks {
+			_, _ = fmt.Fprintf(bw, "%s:%d.%d,%d.%d %d %d\n",
+				p.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, b.ExecCount)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteProfile writes the files of the coverage in the text format of
+// go test -coverprofile, see WriteProfiles.
+func (c *Coverage) WriteProfile(w io.Writer) error {
+	profiles := make([]Profile, 0, len(c.Files))
+	for _, p := range c.Files {
+		profiles = append(profiles, p)
+	}
+
+	return WriteProfiles(w, profiles)
+}
@@ -0,0 +1,76 @@
+package coverage_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+var _ = Describe("Writer", func() {
+	Context("WriteProfile", func() {
+		It("Should write a profile parsing back to the same coverage", func() {
+			cov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			var buf bytes.Buffer
+			Expect(cov.WriteProfile(&buf)).To(Succeed())
+
+			profiles, err := coverage.ParseProfilesFromReader(bytes.NewReader(buf.Bytes()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(coverage.NewCoverage(profiles)).To(Equal(cov))
+		})
+
+		It("Should write the files and blocks in a canonical order", func() {
+			data := strings.Join([]string{
+				"mode: count",
+				"b.go:5.1,6.2 1 1",
+				"a.go:3.1,4.2 2 0",
+				"b.go:1.1,2.2 1 2",
+				"a.go:1.1,2.2 1 1",
+				"b.go:1.1,2.2 1 3",
+			}, "\n")
+
+			profiles, err := coverage.ParseProfilesFromReader(strings.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+
+			var buf bytes.Buffer
+			Expect(coverage.NewCoverage(profiles).WriteProfile(&buf)).To(Succeed())
+			Expect(buf.String()).To(Equal(`mode: count
+a.go:1.1,2.2 1 1
+a.go:3.1,4.2 2 0
+b.go:1.1,2.2 1 5
+b.go:5.1,6.2 1 1
+`))
+		})
+
+		It("Should reject profiles of different modes", func() {
+			err := coverage.WriteProfiles(new(bytes.Buffer), []coverage.Profile{
+				{FileName: "a.go", Mode: "set"},
+				{FileName: "b.go", Mode: "count"},
+			})
+			Expect(err).To(MatchError(`b.go: mode "count" differs from "set"`))
+		})
+
+		It("Should be stable across runs", func() {
+			data, err := os.ReadFile("testdata/02-new-coverage.txt")
+			Expect(err).NotTo(HaveOccurred())
+
+			profiles, err := coverage.ParseProfilesFromReader(bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+
+			var first, second bytes.Buffer
+			Expect(coverage.WriteProfiles(&first, profiles)).To(Succeed())
+
+			reparsed, err := coverage.ParseProfilesFromReader(bytes.NewReader(first.Bytes()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(coverage.WriteProfiles(&second, reparsed)).To(Succeed())
+
+			Expect(second.String()).To(Equal(first.String()))
+		})
+	})
+})
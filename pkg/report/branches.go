@@ -0,0 +1,92 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// hasBranches tells whether the branch coverage is analyzed.
+func hasBranches(conf *config.Config) bool {
+	return conf.Branches || hasThreshold(conf, func(t config.Threshold) int { return t.Branch })
+}
+
+// branchCoverage approximates the branch coverage of the files of the changed
+// packages, for those whose source is found in the modules.
+func branchCoverage(
+	conf *config.Config, cov *coverage.Coverage, changedPackages []string,
+) (files, packages map[string]coverage.Branches) {
+	var (
+		srcs    = Sources(conf)
+		changed = make(map[string]bool, len(changedPackages))
+	)
+
+	files, packages = make(map[string]coverage.Branches), make(map[string]coverage.Branches)
+
+	for _, src := range srcs {
+		src.Directives = conf.Exclude.Directives
+	}
+
+	for _, pkg := range changedPackages {
+		changed[pkg] = true
+	}
+
+	for name, p := range cov.Files {
+		pkg := coverage.PackagePath(name)
+		if !changed[pkg] {
+			continue
+		}
+
+		for _, src := range srcs {
+			if b, ok := src.Branches(p); ok {
+				files[name] = b
+				packages[pkg] = packages[pkg].Add(b)
+
+				break
+			}
+		}
+	}
+
+	return files, packages
+}
+
+// checkBranchCoverage checks the changed files against the branch threshold
+// of their module. Files without branches pass.
+func checkBranchCoverage(conf *config.Config, files map[string]coverage.Branches, changedFiles []string) CoveragePass {
+	var res = CoveragePass{
+		Value:  true,
+		Detail: make(map[string]bool),
+	}
+
+	for _, filename := range changedFiles {
+		threshold := conf.ThresholdOf(filename).Branch
+
+		b, ok := files[filename]
+		if threshold <= 0 || !ok {
+			continue
+		}
+
+		res.Detail[filename] = b.Total == 0 || isCoveragePassed(threshold, b.Percent())
+		if !res.Detail[filename] {
+			res.Value = false
+		}
+	}
+
+	return res
+}
+
+// branchLabel renders a branch coverage, followed by the result of its
+// threshold check if any.
+func branchLabel(symbols config.Symbols, b coverage.Branches, ok bool, pass *bool) string {
+	if !ok || b.Total == 0 {
+		return "-"
+	}
+
+	label := fmt.Sprintf("%.2f%% (%d/%d)", b.Percent(), b.Covered, b.Total)
+	if pass != nil {
+		label += " " + passSymbol(symbols, *pass)
+	}
+
+	return label
+}
@@ -0,0 +1,343 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+const (
+	// budgetReserve is the size kept below the limit when shortening a
+	// report, absorbing the rows not accounted for exactly.
+	budgetReserve = 256
+	// truncatedNote ends a report cut at the size limit, when even its
+	// shortest form doesn't fit.
+	truncatedNote = "\n\n_Truncated to fit the size limit of the comment._"
+)
+
+// budget is the size left to render a report within the size limit of a
+// comment.
+type budget struct {
+	left int
+}
+
+// take reserves size bytes, telling whether they fit.
+func (b *budget) take(size int) bool {
+	if size > b.left {
+		return false
+	}
+
+	b.left -= size
+
+	return true
+}
+
+// fit returns the leading names whose rows fit, one row per line.
+func (b *budget) fit(names, rows []string) []string {
+	for i, row := range rows {
+		if !b.take(len(row) + 1) {
+			return names[:i]
+		}
+	}
+
+	return names
+}
+
+// byPriority returns the names sorted with the failed ones first, then by
+// increasing score, e.g. the biggest regressions first, and then by name.
+func byPriority(names []string, failed func(name string) bool, score func(name string) float64) []string {
+	res := append([]string(nil), names...)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if fi, fj := failed(res[i]), failed(res[j]); fi != fj {
+			return fi
+		}
+
+		if si, sj := score(res[i]), score(res[j]); si != sj {
+			return si < sj
+		}
+
+		return res[i] < res[j]
+	})
+
+	return res
+}
+
+// failedCheck tells whether the check of name failed.
+func failedCheck(pass CoveragePass, name string) bool {
+	passed, ok := pass.Detail[name]
+	return ok && !passed
+}
+
+// addOmitted notes the rows left out of a shortened table.
+func addOmitted(report *strings.Builder, omitted int, kind string) {
+	if omitted == 1 {
+		kind = strings.TrimSuffix(kind, "s")
+	}
+
+	if omitted > 0 {
+		_, _ = fmt.Fprintf(report, "_%d more %s omitted to fit the size limit of the comment._\n\n", omitted, kind)
+	}
+}
+
+// addOmittedSections notes the sections left out of a shortened report.
+func addOmittedSections(report *strings.Builder, sections []string) {
+	if len(sections) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(report, "_Sections omitted to fit the size limit of the comment: %s._\n\n", strings.Join(sections, ", "))
+}
+
+// addWarningCount renders the warnings of a shortened report collapsed to
+// their count.
+func addWarningCount(report *strings.Builder, diags []coverage.Diagnostic) {
+	_, _ = fmt.Fprintln(report, "### Warnings")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintf(report, "**%d** invalid lines of the coverage profiles were skipped.\n", len(diags))
+	_, _ = fmt.Fprintln(report)
+}
+
+// fitLimit cuts the markdown at the last line fitting within limit bytes,
+// noting the truncation, as a last resort when the shortened report is still
+// too big.
+func fitLimit(markdown string, limit int) string {
+	if len(markdown) <= limit {
+		return markdown
+	}
+
+	if limit <= len(truncatedNote) {
+		return cut(markdown, limit)
+	}
+
+	markdown = cut(markdown, limit-len(truncatedNote))
+	if i := strings.LastIndexByte(markdown, '\n'); i > 0 {
+		markdown = markdown[:i]
+	}
+
+	return markdown + truncatedNote
+}
+
+// cut returns the leading n bytes of s, without splitting a rune.
+func cut(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
+
+// addFailures lists the failed checks of a shortened report, whose details
+// may be omitted.
+func addFailures(report *strings.Builder, failures []string) {
+	if len(failures) > 0 {
+		_, _ = fmt.Fprintf(report, "\n\n**Failed checks:** %s", strings.Join(failures, ", "))
+	}
+}
+
+// budgetedMarkdown renders the report within limit bytes: the title, the
+// packages and the files, the failed and most regressed ones first, and the
+// result. The other sections are left out with a note, and the warnings are
+// collapsed to their count.
+func (r *Report) budgetedMarkdown(limit int) string {
+	var (
+		oldPkgs, newPkgs = r.Old.ByPackage(), r.New.ByPackage()
+		b                = budget{left: limit - len(r.shortMarkdown(nil, nil)) - budgetReserve}
+	)
+
+	packages := byPriority(r.ChangedPackages,
+		func(pkg string) bool { return failedCheck(r.PackageCoveragePass, pkg) },
+		func(pkg string) float64 {
+			var oldPercent, newPercent float64
+
+			if cov, ok := oldPkgs[pkg]; ok {
+				oldPercent = cov.Percent()
+			}
+
+			if cov, ok := newPkgs[pkg]; ok {
+				newPercent = cov.Percent()
+			}
+
+			return newPercent - oldPercent
+		},
+	)
+	packages = b.fit(packages, r.packagesTable(packages)[2:])
+
+	codeFiles, _ := r.changedFilesByChange()
+
+	var changed []string
+	for _, section := range fileSections {
+		changed = append(changed, codeFiles[section.change]...)
+	}
+
+	changed = byPriority(changed,
+		func(name string) bool {
+			return failedCheck(r.FileCoveragePass, name) || failedCheck(r.BranchCoveragePass, name)
+		},
+		func(name string) float64 {
+			return r.New.Files[name].CoveragePercent() - r.Old.Files[name].CoveragePercent()
+		},
+	)
+
+	var (
+		files   []string
+		started = make(map[string]bool)
+	)
+
+	for _, name := range changed {
+		var (
+			change = r.Changes[name]
+			table  = r.codeFilesTable(change, []string{name})
+			size   = len(table[2]) + 1
+		)
+
+		if !started[change] {
+			// "### title", a blank line, the header and the separator
+			size += len("### \n\n\n\n") + len(fileSectionTitle(change)) + len(table[0]) + len(table[1])
+		}
+
+		if !b.take(size) {
+			break
+		}
+
+		files = append(files, name)
+		started[change] = true
+	}
+
+	return r.shortMarkdown(packages, files)
+}
+
+// shortMarkdown renders the shortened report holding the given packages and
+// files, in that order.
+func (r *Report) shortMarkdown(packages, files []string) string {
+	report := new(strings.Builder)
+
+	_, _ = fmt.Fprintln(report, r.Title())
+	_, _ = fmt.Fprintln(report, strings.Join(r.packagesTable(packages), "\n"))
+	_, _ = fmt.Fprintln(report)
+
+	addOmitted(report, len(r.ChangedPackages)-len(packages), "packages")
+	addReportLink(report, r.names)
+	addOmittedSections(report, r.omittedSections())
+
+	if len(r.Warnings) > 0 {
+		addWarningCount(report, r.Warnings)
+	}
+
+	_, _ = fmt.Fprintln(report, "---")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintln(report, "<details>")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintln(report, "<summary>Coverage by file</summary>")
+	_, _ = fmt.Fprintln(report)
+
+	codeFiles := make(map[string][]string)
+	for _, name := range files {
+		codeFiles[r.Changes[name]] = append(codeFiles[r.Changes[name]], name)
+	}
+
+	r.addCodeFileSections(report, codeFiles)
+
+	if len(files) > 0 {
+		_, _ = fmt.Fprintln(report)
+	}
+
+	addOmitted(report, len(r.ChangedFiles)-len(files), "files")
+
+	_, _ = fmt.Fprint(report, "</details>")
+
+	if r.hasResult() {
+		addFailures(report, r.Failures())
+		_, _ = fmt.Fprintln(report)
+		r.addTotalCoverageResult(report)
+	}
+
+	return report.String()
+}
+
+// omittedSections returns the name of the sections of the full report left
+// out of the shortened one.
+func (r *Report) omittedSections() []string {
+	codeFiles, _ := r.changedFilesByChange()
+
+	sections := []struct {
+		name string
+		add  func(report *strings.Builder)
+	}{
+		{"modules", func(report *strings.Builder) {
+			if len(r.Modules) > 0 {
+				r.addModules(report)
+			}
+		}},
+		{"coverage tree", func(report *strings.Builder) {
+			if r.Tree != nil {
+				r.addTree(report)
+			}
+		}},
+		{"riskiest uncovered changes", func(report *strings.Builder) {
+			if r.conf.Risk.Top > 0 {
+				r.addRisks(report)
+			}
+		}},
+		{"uncovered lines", func(report *strings.Builder) {
+			if r.names.HasLines() {
+				r.addUncoveredLines(report, codeFiles)
+			}
+		}},
+		{"execution counts", func(report *strings.Builder) {
+			if len(r.Hotness) > 0 {
+				r.addHotness(report)
+			}
+		}},
+	}
+
+	var res []string
+
+	for _, section := range sections {
+		var b strings.Builder
+		if section.add(&b); b.Len() > 0 {
+			res = append(res, section.name)
+		}
+	}
+
+	return res
+}
+
+// fileSectionTitle returns the title of the section of a kind of change.
+func fileSectionTitle(change string) string {
+	for _, section := range fileSections {
+		if section.change == change {
+			return section.title
+		}
+	}
+
+	return ""
+}
+
+// budgetedMarkdown renders the summary within limit bytes, keeping the
+// failed and least covered packages and files first.
+func (s *Summary) budgetedMarkdown(limit int) string {
+	var (
+		packageCovs = s.Coverage.ByPackage()
+		b           = budget{left: limit - len(s.markdown(nil, nil)) - budgetReserve}
+	)
+
+	packages := byPriority(s.Packages,
+		func(pkg string) bool { return failedCheck(s.PackageCoveragePass, pkg) },
+		func(pkg string) float64 { return packageCovs[pkg].Percent() },
+	)
+	packages = b.fit(packages, s.table(s.packagesTable(packages))[2:])
+
+	files := byPriority(s.Files,
+		func(name string) bool {
+			return failedCheck(s.FileCoveragePass, name) || failedCheck(s.BranchCoveragePass, name)
+		},
+		func(name string) float64 { return s.Coverage.Files[name].CoveragePercent() },
+	)
+	files = b.fit(files, s.table(s.filesTable(files))[2:])
+
+	return s.markdown(packages, files)
+}
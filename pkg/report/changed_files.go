@@ -1,48 +1,126 @@
 package report
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/willjunx/go-coverage-report/pkg/config"
 	"github.com/willjunx/go-coverage-report/pkg/coverage"
 )
 
-func GetChangedFiles(oldCov, newCov *coverage.Coverage, excludePaths []string) []string {
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+	// ChangeRenamed marks files renamed or moved since the old coverage, and
+	// compared against their previous selves.
+	ChangeRenamed = "renamed"
+	// ChangeCoverageOnly marks files whose blocks are unchanged but which are
+	// covered differently, e.g. because of new or removed tests.
+	ChangeCoverageOnly = "coverage-only"
+)
+
+// Exclude returns a view of the coverage without the files matched by
+// exclude, so that excluded files neither count in the totals nor in the
+// package aggregates.
+func Exclude(cov *coverage.Coverage, exclude *config.PathMatcher) *coverage.Coverage {
+	return cov.Filter(func(fileName string) bool {
+		return !exclude.Match(fileName)
+	})
+}
+
+// ExcludeSource returns views of both coverages without the generated files
+// and the code annotated with //coverage:ignore, as enabled by the config.
+// The blocks dropped from the new coverage are also dropped from the old
+// one. Both coverages are returned unchanged with coverage.ErrModuleNotFound
+// when the source of the modules is not found.
+func ExcludeSource(conf *config.Config, oldCov, newCov *coverage.Coverage) (*coverage.Coverage, *coverage.Coverage, error) {
+	if !conf.Exclude.Generated && !conf.Exclude.Directives {
+		return oldCov, newCov, nil
+	}
+
+	srcs := Sources(conf)
+	if len(srcs) == 0 {
+		return oldCov, newCov, coverage.ErrModuleNotFound
+	}
+
+	for _, src := range srcs {
+		src.Generated, src.Directives = conf.Exclude.Generated, conf.Exclude.Directives
+		oldCov, newCov = src.ApplyBaseline(oldCov, newCov), src.Apply(newCov)
+	}
+
+	return oldCov, newCov, nil
+}
+
+// GetChangedFiles returns the files whose blocks or coverage differ between
+// both coverages, including the files missing from either of them.
+func GetChangedFiles(oldCov, newCov *coverage.Coverage, exclude *config.PathMatcher) []string {
 	var (
 		oldFiles, newFiles = oldCov.Files, newCov.Files
 		res                = make([]string, 0, max(len(oldFiles), len(newFiles)))
-		excludeRules       = compileExcludePathRules(excludePaths)
 	)
 
 	for newFile, newProfile := range newFiles {
-		if ok := matches(excludeRules, newFile); ok {
+		if exclude.Match(newFile) {
 			continue // this file is excluded
 		}
 
 		oldProfile, ok := oldFiles[newFile]
-		isNew := !ok
-		isChange := newProfile.CoveragePercent() != oldProfile.CoveragePercent() ||
-			len(newProfile.Blocks) != len(oldProfile.Blocks)
-
-		if !isChange {
-			for i, block := range newProfile.Blocks {
-				if !block.Equal(oldProfile.Blocks[i]) {
-					isChange = true
-					break
-				}
-			}
+		if !ok || blocksChanged(oldProfile, newProfile) ||
+			newProfile.CoveragePercent() != oldProfile.CoveragePercent() {
+			res = append(res, newFile)
 		}
+	}
 
-		if isNew || isChange {
-			res = append(res, newFile)
+	for oldFile := range oldFiles {
+		if _, ok := newFiles[oldFile]; !ok && !exclude.Match(oldFile) {
+			res = append(res, oldFile)
 		}
 	}
 
 	return res
 }
 
+// ChangeOf classifies the change of a file between both coverages. Files
+// found in neither coverage, e.g. files without statements, are considered
+// modified.
+func ChangeOf(oldCov, newCov *coverage.Coverage, fileName string) string {
+	oldProfile, inOld := oldCov.Files[fileName]
+	newProfile, inNew := newCov.Files[fileName]
+
+	switch {
+	case inNew && !inOld:
+		return ChangeAdded
+	case inOld && !inNew:
+		return ChangeRemoved
+	case inOld && !blocksChanged(oldProfile, newProfile) &&
+		newProfile.CoveragePercent() != oldProfile.CoveragePercent():
+		return ChangeCoverageOnly
+	default:
+		return ChangeModified
+	}
+}
+
+func blocksChanged(oldProfile, newProfile coverage.Profile) bool {
+	if len(newProfile.Blocks) != len(oldProfile.Blocks) {
+		return true
+	}
+
+	for i, block := range newProfile.Blocks {
+		if !block.Equal(oldProfile.Blocks[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func ParseChangedFiles(filename, prefix string) ([]string, error) {
 	data, err := os.ReadFile(filepath.Clean(filename))
 	if err != nil {
@@ -57,32 +135,124 @@ func ParseChangedFiles(filename, prefix string) ([]string, error) {
 	}
 
 	for i, file := range files {
-		files[i] = filepath.Join(prefix, file)
+		files[i] = path.Join(prefix, filepath.ToSlash(file))
 	}
 
 	return files, nil
 }
 
-func compileExcludePathRules(excludePaths []string) []*regexp.Regexp {
-	if len(excludePaths) == 0 {
-		return nil
+// ParseRenames reads the renames from the output of
+// "git diff --name-status -M", keyed by the new file name with the old one as
+// value. The prefix is added to both names like in ParseChangedFiles.
+func ParseRenames(filename, prefix string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
 	}
 
-	compiled := make([]*regexp.Regexp, len(excludePaths))
+	var (
+		res = make(map[string]string)
+		s   = bufio.NewScanner(bytes.NewReader(data))
+	)
 
-	for i, pattern := range excludePaths {
-		compiled[i] = regexp.MustCompile(pattern)
+	for s.Scan() {
+		fields := strings.Split(s.Text(), "\t")
+		if len(fields) != 3 || !strings.HasPrefix(fields[0], "R") {
+			continue
+		}
+
+		oldFile, newFile := path.Join(prefix, filepath.ToSlash(fields[1])), path.Join(prefix, filepath.ToSlash(fields[2]))
+		res[newFile] = oldFile
 	}
 
-	return compiled
+	return res, s.Err()
 }
 
-func matches(regexps []*regexp.Regexp, str string) bool {
-	for _, r := range regexps {
-		if r.MatchString(str) {
-			return true
+// DetectRenames pairs the removed and added files among the changed files,
+// keyed by the new file name with the old one as value. The known renames,
+// e.g. from git, are used first; the remaining files are paired by the
+// similarity of their blocks, when at least similarity percent, preferring
+// files keeping their base name.
+func DetectRenames(oldCov, newCov *coverage.Coverage, changedFiles []string, known map[string]string, similarity int) map[string]string {
+	var (
+		res              = make(map[string]string)
+		removed, added   = make(map[string]bool), make(map[string]bool)
+		candidates       []renameCandidate
+		pairedOld        = make(map[string]bool)
+		removedN, addedN []string
+	)
+
+	for _, file := range changedFiles {
+		switch ChangeOf(oldCov, newCov, file) {
+		case ChangeRemoved:
+			removed[file] = true
+		case ChangeAdded:
+			added[file] = true
 		}
 	}
 
-	return false
+	for newFile, oldFile := range known {
+		if added[newFile] && removed[oldFile] {
+			res[newFile], pairedOld[oldFile] = oldFile, true
+		}
+	}
+
+	if similarity <= 0 {
+		return res
+	}
+
+	for file := range removed {
+		if !pairedOld[file] {
+			removedN = append(removedN, file)
+		}
+	}
+
+	for file := range added {
+		if _, ok := res[file]; !ok {
+			addedN = append(addedN, file)
+		}
+	}
+
+	for _, oldFile := range removedN {
+		for _, newFile := range addedN {
+			score := coverage.Similarity(oldCov.Files[oldFile], newCov.Files[newFile])
+			if score*100 >= float64(similarity) {
+				candidates = append(candidates, renameCandidate{
+					oldFile: oldFile, newFile: newFile, score: score,
+					sameName: path.Base(oldFile) == path.Base(newFile),
+				})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].less(candidates[j]) })
+
+	for _, c := range candidates {
+		if _, ok := res[c.newFile]; ok || pairedOld[c.oldFile] {
+			continue
+		}
+
+		res[c.newFile], pairedOld[c.oldFile] = c.oldFile, true
+	}
+
+	return res
+}
+
+type renameCandidate struct {
+	oldFile, newFile string
+	score            float64
+	sameName         bool
+}
+
+func (c renameCandidate) less(o renameCandidate) bool {
+	switch {
+	case c.score != o.score:
+		return c.score > o.score
+	case c.sameName != o.sameName:
+		return c.sameName
+	case c.newFile != o.newFile:
+		return c.newFile < o.newFile
+	default:
+		return c.oldFile < o.oldFile
+	}
 }
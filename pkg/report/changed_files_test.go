@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/willjunx/go-coverage-report/pkg/config"
 	"github.com/willjunx/go-coverage-report/pkg/coverage"
 	"github.com/willjunx/go-coverage-report/pkg/report"
 )
@@ -21,6 +22,44 @@ var _ = Describe("Report", func() {
 			Expect(changedFiles).To(Equal([]string{"github.com/username/prioqueue/min_heap.go"}))
 		})
 
+		When("files are added, removed or covered differently", func() {
+			oldCov := coverage.NewCoverage([]coverage.Profile{
+				{FileName: "example.com/mod/kept.go", Blocks: []coverage.ProfileBlock{{StartLine: 1, EndLine: 2, NumStmt: 2}}},
+				{FileName: "example.com/mod/tested.go", TotalStmt: 2, Blocks: []coverage.ProfileBlock{{StartLine: 1, EndLine: 2, NumStmt: 2}}},
+				{FileName: "example.com/mod/edited.go", Blocks: []coverage.ProfileBlock{{StartLine: 1, EndLine: 2, NumStmt: 2}}},
+				{FileName: "example.com/mod/removed.go", TotalStmt: 2, CoveredStmt: 2},
+			})
+			newCov := coverage.NewCoverage([]coverage.Profile{
+				{FileName: "example.com/mod/kept.go", Blocks: []coverage.ProfileBlock{{StartLine: 1, EndLine: 2, NumStmt: 2}}},
+				{
+					FileName: "example.com/mod/tested.go", TotalStmt: 2, CoveredStmt: 2,
+					Blocks: []coverage.ProfileBlock{{StartLine: 1, EndLine: 2, NumStmt: 2, ExecCount: 1}},
+				},
+				{FileName: "example.com/mod/edited.go", Blocks: []coverage.ProfileBlock{{StartLine: 1, EndLine: 3, NumStmt: 2}}},
+				{FileName: "example.com/mod/added.go", TotalStmt: 1},
+			})
+
+			It("Should return every changed file", func() {
+				Expect(report.GetChangedFiles(oldCov, newCov, nil)).To(ConsistOf(
+					"example.com/mod/tested.go",
+					"example.com/mod/edited.go",
+					"example.com/mod/removed.go",
+					"example.com/mod/added.go",
+				))
+			})
+
+			DescribeTable("ChangeOf",
+				func(file, change string) {
+					Expect(report.ChangeOf(oldCov, newCov, file)).To(Equal(change))
+				},
+				Entry("added", "example.com/mod/added.go", report.ChangeAdded),
+				Entry("removed", "example.com/mod/removed.go", report.ChangeRemoved),
+				Entry("modified", "example.com/mod/edited.go", report.ChangeModified),
+				Entry("coverage only", "example.com/mod/tested.go", report.ChangeCoverageOnly),
+				Entry("missing from both coverages", "example.com/mod/doc.go", report.ChangeModified),
+			)
+		})
+
 		When("with exclude paths", func() {
 			It("should return correctly", func() {
 				oldCov, err := coverage.NewCoverageFromFile("testdata/02-old-coverage.txt")
@@ -29,10 +68,87 @@ var _ = Describe("Report", func() {
 				newCov, err := coverage.NewCoverageFromFile("testdata/02-new-coverage.txt")
 				Expect(err).ToNot(HaveOccurred())
 
-				changedFiles := report.GetChangedFiles(oldCov, newCov, []string{"^github.com/username"})
+				exclude, err := config.Exclude{Paths: []config.PathRule{{Pattern: "^github.com/username"}}}.Matcher("")
+				Expect(err).ToNot(HaveOccurred())
+
+				changedFiles := report.GetChangedFiles(oldCov, newCov, exclude)
 				Expect(changedFiles).To(Equal([]string{}))
 			})
 		})
 	})
 
+	Context("Renames", func() {
+		blocks := []coverage.ProfileBlock{
+			{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 2, NumStmt: 1, ExecCount: 1},
+			{StartLine: 7, StartCol: 30, EndLine: 12, EndCol: 2, NumStmt: 3},
+		}
+		other := []coverage.ProfileBlock{{StartLine: 3, StartCol: 10, EndLine: 4, EndCol: 2, NumStmt: 2}}
+
+		oldCov := coverage.NewCoverage([]coverage.Profile{
+			{FileName: "example.com/mod/a/moved.go", Blocks: blocks, TotalStmt: 4, CoveredStmt: 1, MissedStmt: 3},
+			{FileName: "example.com/mod/a/gone.go", Blocks: other, TotalStmt: 2, MissedStmt: 2},
+			{FileName: "example.com/mod/a/old.go", Blocks: other, TotalStmt: 2, MissedStmt: 2},
+		})
+		newCov := coverage.NewCoverage([]coverage.Profile{
+			{FileName: "example.com/mod/b/moved.go", Blocks: blocks, TotalStmt: 4, CoveredStmt: 1, MissedStmt: 3},
+			{FileName: "example.com/mod/b/copy.go", Blocks: blocks, TotalStmt: 4, CoveredStmt: 1, MissedStmt: 3},
+			{FileName: "example.com/mod/a/new.go", Blocks: other, TotalStmt: 2, CoveredStmt: 2},
+		})
+		changedFiles := report.GetChangedFiles(oldCov, newCov, nil)
+
+		It("Should pair similar files, preferring those keeping their name", func() {
+			renames := report.DetectRenames(oldCov, newCov, changedFiles, nil, 80)
+			Expect(renames).To(Equal(map[string]string{
+				"example.com/mod/b/moved.go": "example.com/mod/a/moved.go",
+				"example.com/mod/a/new.go":   "example.com/mod/a/gone.go",
+			}))
+		})
+
+		It("Should use the known renames first", func() {
+			renames := report.DetectRenames(oldCov, newCov, changedFiles, map[string]string{
+				"example.com/mod/a/new.go": "example.com/mod/a/old.go",
+				"example.com/mod/c/x.go":   "example.com/mod/a/moved.go",
+			}, 80)
+			Expect(renames).To(Equal(map[string]string{
+				"example.com/mod/b/moved.go": "example.com/mod/a/moved.go",
+				"example.com/mod/a/new.go":   "example.com/mod/a/old.go",
+			}))
+		})
+
+		It("Should not compare blocks when disabled", func() {
+			Expect(report.DetectRenames(oldCov, newCov, changedFiles, nil, 0)).To(BeEmpty())
+		})
+
+		It("Should parse the renames of git", func() {
+			renames, err := report.ParseRenames("testdata/renames.txt", "github.com/username/prioqueue")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(renames).To(Equal(map[string]string{
+				"github.com/username/prioqueue/queue/new.go":       "github.com/username/prioqueue/queue/old.go",
+				"github.com/username/prioqueue/internal/legacy.go": "github.com/username/prioqueue/legacy.go",
+			}))
+		})
+	})
+
+	Context("Exclude", func() {
+		It("Should drop excluded files from totals and packages", func() {
+			oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			exclude, err := config.Exclude{Paths: []config.PathRule{
+				{Pattern: "min_heap.go", Type: config.RuleGitignore},
+			}}.Matcher("github.com/username/prioqueue")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Threshold.Total = 95
+
+			rep := report.New(&cfg, report.Exclude(oldCov, exclude), report.Exclude(newCov, exclude),
+				[]string{"github.com/username/prioqueue/max_heap.go"})
+			Expect(rep.Title()).To(HavePrefix("## Coverage Percentage 100.00%"))
+			Expect(rep.TotalCoveragePass).To(BeTrue())
+		})
+	})
 })
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"encoding/xml"
+	"path"
+	"sort"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+const coberturaHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+`
+
+type coberturaCoverage struct {
+	XMLName       xml.Name           `xml:"coverage"`
+	LineRate      float64            `xml:"line-rate,attr"`
+	BranchRate    float64            `xml:"branch-rate,attr"`
+	LinesCovered  int                `xml:"lines-covered,attr"`
+	LinesValid    int                `xml:"lines-valid,attr"`
+	BranchesValid int                `xml:"branches-valid,attr"`
+	Complexity    float64            `xml:"complexity,attr"`
+	Version       string             `xml:"version,attr"`
+	Sources       []string           `xml:"sources>source"`
+	Packages      []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   float64         `xml:"line-rate,attr"`
+	BranchRate float64         `xml:"branch-rate,attr"`
+	Complexity float64         `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// Cobertura renders the new coverage in the Cobertura XML format, see
+// Cobertura. Files and packages are named by their display names.
+func (r *Report) Cobertura() (string, error) {
+	return cobertura(r.New, r.names)
+}
+
+// Cobertura renders the coverage in the Cobertura XML format. Go profiles are
+// block based, so every line of a block is reported with the block's
+// execution count and the line rate is derived from those lines.
+func Cobertura(cov *coverage.Coverage) (string, error) {
+	return cobertura(cov, nil)
+}
+
+func cobertura(cov *coverage.Coverage, display *config.DisplayNames) (string, error) {
+	var (
+		doc      = coberturaCoverage{Sources: []string{"."}}
+		packages = cov.ByPackage()
+		names    = make([]string, 0, len(packages))
+	)
+
+	for name := range packages {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		pkg := coberturaPackage{Name: display.Name(name)}
+
+		var pkgCovered, pkgValid int
+
+		for _, profile := range sortedProfiles(packages[name]) {
+			class, covered, valid := coberturaClassOf(profile, display.Name(profile.FileName))
+			pkg.Classes = append(pkg.Classes, class)
+			pkgCovered += covered
+			pkgValid += valid
+		}
+
+		pkg.LineRate = rate(pkgCovered, pkgValid)
+		doc.Packages = append(doc.Packages, pkg)
+		doc.LinesCovered += pkgCovered
+		doc.LinesValid += pkgValid
+	}
+
+	doc.LineRate = rate(doc.LinesCovered, doc.LinesValid)
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return coberturaHeader + string(data), nil
+}
+
+func coberturaClassOf(profile coverage.Profile, fileName string) (class coberturaClass, covered, valid int) {
+	hits := make(map[int]int)
+
+	for _, b := range profile.Blocks {
+		for line := b.StartLine; line <= b.EndLine; line++ {
+			hits[line] = max(hits[line], b.ExecCount)
+		}
+	}
+
+	class = coberturaClass{
+		Name:     path.Base(fileName),
+		Filename: fileName,
+		Lines:    make([]coberturaLine, 0, len(hits)),
+	}
+
+	for line, count := range hits {
+		class.Lines = append(class.Lines, coberturaLine{Number: line, Hits: count})
+
+		if count > 0 {
+			covered++
+		}
+	}
+
+	sort.Slice(class.Lines, func(i, j int) bool { return class.Lines[i].Number < class.Lines[j].Number })
+
+	class.LineRate = rate(covered, len(hits))
+
+	return class, covered, len(hits)
+}
+
+func sortedProfiles(cov *coverage.Coverage) []coverage.Profile {
+	profiles := make([]coverage.Profile, 0, len(cov.Files))
+	for _, p := range cov.Files {
+		profiles = append(profiles, p)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].FileName < profiles[j].FileName })
+
+	return profiles
+}
+
+func rate(covered, valid int) float64 {
+	if valid == 0 {
+		return 0
+	}
+
+	return roundFloat(float64(covered)/float64(valid), 4)
+}
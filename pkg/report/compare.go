@@ -0,0 +1,324 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// Options configures Compare.
+type Options struct {
+	// Config holds the thresholds, exclusions and report settings; nil uses
+	// config.Default. It is copied and not modified.
+	Config *config.Config
+	// ChangedFiles lists the files to compare; nil compares the files whose
+	// blocks or coverage differ between both coverages.
+	ChangedFiles []string
+	// Renames holds the previous name of renamed files keyed by their new
+	// name, e.g. from ParseRenames. Other renames are detected according to
+	// Config.Renames.
+	Renames map[string]string
+	// Packages reads the package names from the source, e.g.
+	// coverage.NewPackages(Sources(conf)...); nil derives them from the
+	// import paths.
+	Packages *coverage.Packages
+}
+
+// Statements counts the statements of a file, a package or a coverage.
+type Statements struct {
+	Total   int
+	Covered int
+	Missed  int
+}
+
+// Percent returns the percentage of covered statements.
+func (s Statements) Percent() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+
+	return float64(s.Covered) / float64(s.Total) * 100
+}
+
+// Comparison holds the statements of the old and the new coverage.
+type Comparison struct {
+	Old Statements
+	New Statements
+}
+
+// Delta returns the change of the coverage percentage.
+func (c Comparison) Delta() float64 {
+	return c.New.Percent() - c.Old.Percent()
+}
+
+// Check is the result of a threshold check.
+type Check struct {
+	// Checked tells whether a threshold applies.
+	Checked bool
+	Passed  bool
+}
+
+// FileResult is the comparison of a changed file.
+type FileResult struct {
+	Name string
+	// OldName is the previous name of a renamed file.
+	OldName string `json:",omitempty"`
+	// Change is the kind of change, see ChangeOf.
+	Change string
+	Comparison
+	Check Check
+}
+
+// PackageResult is the comparison of a changed package.
+type PackageResult struct {
+	// Path is the import path of the package and Name its name.
+	Path string
+	Name string `json:",omitempty"`
+	Comparison
+	Check Check
+}
+
+// Result is the outcome of Compare. It is immutable: its methods return
+// copies and the coverages it was built from are left untouched. Files and
+// packages are named by their full import path; the display names of the
+// config only apply to the rendered reports.
+type Result struct {
+	report   *Report
+	total    Comparison
+	files    []FileResult
+	packages []PackageResult
+	failures []string
+}
+
+// Compare compares the coverage of the changed files between the old and
+// the new coverage, and checks the new one against the thresholds of the
+// config. The exclusions of the config apply like in the command line, the
+// source based ones being skipped when the source is not found. The
+// coverages are not modified. The context is checked between the steps of
+// the comparison, some of which read the source of the files.
+func Compare(ctx context.Context, oldCov, newCov *coverage.Coverage, opts Options) (*Result, error) {
+	conf := config.Default
+	if opts.Config != nil {
+		conf = *opts.Config
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	oldCov, newCov, err := ExcludeSource(&conf, oldCov, newCov)
+	if err != nil && !errors.Is(err, coverage.ErrModuleNotFound) {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	exclude, err := conf.Exclude.Matcher(conf.RootPackage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Exclude returns copies, which the report may rename.
+	oldCov, newCov = Exclude(oldCov, exclude), Exclude(newCov, exclude)
+
+	changedFiles := append([]string(nil), opts.ChangedFiles...)
+	if opts.ChangedFiles == nil {
+		changedFiles = GetChangedFiles(oldCov, newCov, exclude)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	renames := DetectRenames(oldCov, newCov, changedFiles, opts.Renames, conf.Renames.Similarity)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r := NewWithRenames(&conf, oldCov, newCov, changedFiles, renames)
+	if opts.Packages != nil {
+		r.PackageNames = opts.Packages.Names(r.ChangedPackages)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		report:   r,
+		total:    Comparison{Old: statementsOf(r.Old), New: statementsOf(r.New)},
+		files:    fileResults(r),
+		packages: packageResults(r),
+		failures: r.Failures(),
+	}, nil
+}
+
+// Passed tells whether the new coverage passes every check of the config.
+func (res *Result) Passed() bool {
+	return len(res.failures) == 0
+}
+
+// Failures returns the checks the new coverage fails, see CheckTotal.
+func (res *Result) Failures() []string {
+	return append([]string(nil), res.failures...)
+}
+
+// Empty tells whether no file changed, in which case there is nothing to
+// report.
+func (res *Result) Empty() bool {
+	return len(res.files) == 0
+}
+
+// Total returns the statements of the old and the new coverage.
+func (res *Result) Total() Comparison {
+	return res.total
+}
+
+// Files returns the comparison of the changed files, sorted by name.
+func (res *Result) Files() []FileResult {
+	return append([]FileResult(nil), res.files...)
+}
+
+// Packages returns the comparison of the changed packages, sorted by import
+// path.
+func (res *Result) Packages() []PackageResult {
+	return append([]PackageResult(nil), res.packages...)
+}
+
+func fileResults(r *Report) []FileResult {
+	files := make([]FileResult, 0, len(r.ChangedFiles))
+
+	for _, name := range r.ChangedFiles {
+		oldProfile, newProfile := r.Old.Files[name], r.New.Files[name]
+		pass, checked := r.FileCoveragePass.Detail[name]
+
+		files = append(files, FileResult{
+			Name:    name,
+			OldName: r.Renames[name],
+			Change:  r.Changes[name],
+			Comparison: Comparison{
+				Old: Statements{oldProfile.TotalStmt, oldProfile.CoveredStmt, oldProfile.MissedStmt},
+				New: Statements{newProfile.TotalStmt, newProfile.CoveredStmt, newProfile.MissedStmt},
+			},
+			Check: Check{Checked: checked, Passed: pass},
+		})
+	}
+
+	return files
+}
+
+func packageResults(r *Report) []PackageResult {
+	var (
+		oldPkgs, newPkgs = r.Old.ByPackage(), r.New.ByPackage()
+		packages         = make([]PackageResult, 0, len(r.ChangedPackages))
+	)
+
+	for _, pkg := range r.ChangedPackages {
+		pass, checked := r.PackageCoveragePass.Detail[pkg]
+
+		packages = append(packages, PackageResult{
+			Path: pkg,
+			Name: r.PackageNames[pkg],
+			Comparison: Comparison{
+				Old: statementsOf(oldPkgs[pkg]),
+				New: statementsOf(newPkgs[pkg]),
+			},
+			Check: Check{Checked: checked, Passed: pass},
+		})
+	}
+
+	return packages
+}
+
+func statementsOf(cov *coverage.Coverage) Statements {
+	if cov == nil {
+		return Statements{}
+	}
+
+	return Statements{Total: cov.TotalStmt, Covered: cov.CoveredStmt, Missed: cov.MissedStmt}
+}
+
+// Write renders the result in the given output format, see Formats.
+func (res *Result) Write(w io.Writer, format string) error {
+	return res.report.Write(w, format)
+}
+
+// WriteMarkdown renders the result as the markdown of a pull request
+// comment.
+func (res *Result) WriteMarkdown(w io.Writer) error {
+	return res.report.WriteMarkdown(w)
+}
+
+// WriteJSON renders the result as JSON.
+func (res *Result) WriteJSON(w io.Writer) error {
+	return res.report.WriteJSON(w)
+}
+
+// WriteCobertura renders the new coverage in the Cobertura XML format.
+func (res *Result) WriteCobertura(w io.Writer) error {
+	return res.report.WriteCobertura(w)
+}
+
+// WriteHTML renders the coverage tree as a standalone HTML page.
+func (res *Result) WriteHTML(w io.Writer) error {
+	return res.report.WriteHTML(w)
+}
+
+// Write renders the report in the given output format.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return r.WriteMarkdown(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatCobertura:
+		return r.WriteCobertura(w)
+	case FormatHTML:
+		return r.WriteHTML(w)
+	default:
+		return fmt.Errorf("unsupported format: %q", format)
+	}
+}
+
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	_, err := io.WriteString(w, r.Markdown())
+	return err
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+
+	return enc.Encode(r)
+}
+
+// renderJSON returns the JSON written by write, without the trailing newline
+// of the encoder.
+func renderJSON(write func(w io.Writer) error) (string, error) {
+	b := new(strings.Builder)
+	if err := write(b); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func (r *Report) WriteCobertura(w io.Writer) error {
+	content, err := r.Cobertura()
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, content)
+
+	return err
+}
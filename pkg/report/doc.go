@@ -0,0 +1,13 @@
+// Package report compares Go coverage profiles and renders the result as a
+// pull request comment, JSON or Cobertura XML.
+//
+// Programs embedding the report should use Compare with Options, and read
+// the returned Result through its methods and value types (Statements,
+// Comparison, Check, FileResult and PackageResult). These follow semantic
+// versioning: they are only changed in a backward compatible way within a
+// major version. Compare never modifies the coverages it is given, and a
+// Result cannot be modified once returned.
+//
+// Report and the functions building it back the go-coverage-report command.
+// They are exported for the command only and may change in any release.
+package report
@@ -0,0 +1,40 @@
+package report_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+	"github.com/willjunx/go-coverage-report/pkg/report"
+)
+
+func ExampleCompare() {
+	oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	newCov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conf := config.Default
+	conf.Threshold.File = 90
+
+	res, err := report.Compare(context.Background(), oldCov, newCov, report.Options{Config: &conf})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, f := range res.Files() {
+		fmt.Printf("%s: %.2f%% (%+.2f%%) passed=%t\n", f.Name, f.New.Percent(), f.Delta(), f.Check.Passed)
+	}
+
+	fmt.Println("failures:", res.Failures())
+	// Output:
+	// github.com/username/prioqueue/min_heap.go: 80.77% (-19.23%) passed=false
+	// failures: [file coverage]
+}
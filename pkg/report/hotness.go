@@ -0,0 +1,88 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// maxOnceLines limits the line ranges listed per file in the markdown report.
+const maxOnceLines = 5
+
+// FileHotness is the execution count analysis of a changed file.
+type FileHotness struct {
+	coverage.Hotness
+	coverage.CountChanges
+	// OnceDelta is the change of the statements executed only once since
+	// the old coverage.
+	OnceDelta int
+}
+
+// hotness analyzes the changed files of profiles recording execution counts.
+func hotness(oldCov, newCov *coverage.Coverage, changedFiles []string) map[string]FileHotness {
+	res := make(map[string]FileHotness)
+
+	for _, file := range changedFiles {
+		newProfile, ok := newCov.Files[file]
+		if !ok {
+			continue
+		}
+
+		h, ok := newProfile.Hotness()
+		if !ok {
+			continue
+		}
+
+		oldProfile := oldCov.Files[file]
+		oldHotness, _ := oldProfile.Hotness()
+
+		res[file] = FileHotness{
+			Hotness:      h,
+			CountChanges: coverage.CompareCounts(oldProfile, newProfile),
+			OnceDelta:    h.Once - oldHotness.Once,
+		}
+	}
+
+	return res
+}
+
+func (r *Report) addHotness(report *strings.Builder) {
+	_, _ = fmt.Fprintln(report, "### Execution counts")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintln(report, "| File | Once | 2-9 | 10-99 | 100+ | Blocks ↑ / ↓ | Lines executed once |")
+	_, _ = fmt.Fprintln(report, "|------|------|-----|-------|------|--------------|---------------------|")
+
+	for _, name := range r.ChangedFiles {
+		h, ok := r.Hotness[name]
+		if !ok {
+			continue
+		}
+
+		once := fmt.Sprintf("%d", h.Once)
+		if h.OnceDelta != 0 {
+			once += fmt.Sprintf(" (%+d)", h.OnceDelta)
+		}
+
+		counts := fmt.Sprintf("%d / %d", h.Increased, h.Decreased)
+		if h.Lost > 0 {
+			counts += fmt.Sprintf(" (%d lost)", h.Lost)
+		}
+
+		lines := make([]string, 0, maxOnceLines+1)
+
+		for i, l := range h.OnceLines {
+			if i == maxOnceLines {
+				lines = append(lines, "…")
+				break
+			}
+
+			lines = append(lines, link(l.String(), r.names.LinesURL(name, l.Start, l.End)))
+		}
+
+		_, _ = fmt.Fprintf(report, "| %s | %s | %d | %d | %d | %s | %s |\n",
+			link(r.names.Name(name), r.names.FileURL(name)), once, h.Few, h.Many, h.Hot, counts, strings.Join(lines, ", "))
+	}
+
+	_, _ = fmt.Fprintln(report)
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// HTML renders the coverage tree of the report as a standalone HTML page,
+// whether or not the tree is part of the markdown, see config.Tree.
+func (r *Report) HTML() string {
+	tree := r.Tree
+	if tree == nil {
+		tree = buildTree(r.conf.RootPackage, r.Old, r.New, r.ChangedFiles, r.conf.Tree.Depth)
+	}
+
+	return htmlPage(fmt.Sprintf("Coverage Percentage %.2f%%", r.New.Percent()), r.htmlTree(), tree)
+}
+
+func (r *Report) WriteHTML(w io.Writer) error {
+	_, err := io.WriteString(w, r.HTML())
+	return err
+}
+
+// HTML renders the coverage tree of the summary as a standalone HTML page,
+// without deltas as there is no baseline.
+func (s *Summary) HTML() string {
+	var (
+		tree     = buildTree(s.conf.RootPackage, coverage.NewCoverage(nil), s.Coverage, s.Files, s.conf.Tree.Depth)
+		renderer = htmlTree{symbols: s.symbols, names: s.names, pass: s.DirectoryCoveragePass}
+	)
+
+	return htmlPage(fmt.Sprintf("Coverage Percentage %.2f%%", s.Coverage.Percent()), renderer, tree)
+}
+
+// htmlPage renders the tree below a title as a standalone HTML page.
+func htmlPage(title string, renderer htmlTree, tree *TreeNode) string {
+	page := new(strings.Builder)
+
+	_, _ = fmt.Fprintln(page, "<!DOCTYPE html>")
+	_, _ = fmt.Fprintln(page, `<html lang="en">`)
+	_, _ = fmt.Fprintln(page, "<head>")
+	_, _ = fmt.Fprintln(page, `<meta charset="utf-8">`)
+	_, _ = fmt.Fprintf(page, "<title>%s</title>\n", html.EscapeString(title))
+	_, _ = fmt.Fprintln(page, "</head>")
+	_, _ = fmt.Fprintln(page, "<body>")
+	_, _ = fmt.Fprintf(page, "<h1>%s</h1>\n", html.EscapeString(title))
+	_, _ = fmt.Fprintln(page, "<ul>")
+	renderer.addNode(page, tree)
+	_, _ = fmt.Fprintln(page, "</ul>")
+	_, _ = fmt.Fprintln(page, "</body>")
+	_, _ = fmt.Fprint(page, "</html>")
+
+	return page.String()
+}
@@ -0,0 +1,143 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// ModuleCoverage is the coverage of a single module of a report spanning
+// several modules.
+type ModuleCoverage struct {
+	Path         string
+	OldPercent   float64
+	NewPercent   float64
+	TotalStmt    int
+	CoveredStmt  int
+	MissedStmt   int
+	ChangedFiles int
+	// TotalCoveragePass checks the module against its total threshold.
+	TotalCoveragePass bool
+}
+
+// moduleCoverages computes the coverage of the configured modules found in
+// either coverage. Each file belongs to the module with the longest matching
+// path.
+func moduleCoverages(conf *config.Config, oldCov, newCov *coverage.Coverage, changedFiles []string) []ModuleCoverage {
+	var res []ModuleCoverage
+
+	for _, m := range conf.Modules {
+		inModule := func(fileName string) bool {
+			owner, ok := conf.ModuleOf(fileName)
+			return ok && owner.Path == m.Path
+		}
+
+		oldMod, newMod := oldCov.Filter(inModule), newCov.Filter(inModule)
+		if len(oldMod.Files) == 0 && len(newMod.Files) == 0 {
+			continue
+		}
+
+		mod := ModuleCoverage{
+			Path:              m.Path,
+			OldPercent:        oldMod.Percent(),
+			NewPercent:        newMod.Percent(),
+			TotalStmt:         newMod.TotalStmt,
+			CoveredStmt:       newMod.CoveredStmt,
+			MissedStmt:        newMod.MissedStmt,
+			TotalCoveragePass: isCoveragePassed(conf.ThresholdOf(m.Path).Total, newMod.Percent()),
+		}
+
+		for _, file := range changedFiles {
+			if inModule(file) {
+				mod.ChangedFiles++
+			}
+		}
+
+		res = append(res, mod)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Path < res[j].Path })
+
+	return res
+}
+
+// modulesPass tells whether every module passes its total threshold.
+func modulesPass(modules []ModuleCoverage) bool {
+	for _, m := range modules {
+		if !m.TotalCoveragePass {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *Report) addModules(report *strings.Builder) {
+	var (
+		header    = "| Module | Coverage Δ | Changed Files |"
+		separator = "|--------|------------|---------------|"
+		hasCheck  = hasThreshold(r.conf, func(t config.Threshold) int { return t.Total })
+	)
+
+	if r.hasTrend() {
+		header += fmt.Sprintf(" %s |", r.symbols.Trend)
+		separator += "---------|"
+	}
+
+	if hasCheck {
+		header += " Pass |"
+		separator += "------|"
+	}
+
+	_, _ = fmt.Fprintln(report, header)
+	_, _ = fmt.Fprintln(report, separator)
+
+	for _, m := range r.Modules {
+		symbol, diffStr := scoreSymbol(r.symbols, m.NewPercent, m.OldPercent)
+
+		format := "| %s | %.2f%% (%s) | %d |"
+		args := []any{m.Path, m.NewPercent, diffStr, m.ChangedFiles}
+
+		if r.hasTrend() {
+			format += " %s |"
+
+			args = append(args, symbol)
+		}
+
+		if hasCheck {
+			format += " %s |"
+
+			args = append(args, passSymbol(r.symbols, m.TotalCoveragePass))
+		}
+
+		_, _ = fmt.Fprintf(report, format+"\n", args...)
+	}
+
+	_, _ = fmt.Fprintln(report)
+}
+
+// Sources returns the Source of every configured module with a directory, and
+// of the module holding the source directory, if not already part of them.
+func Sources(conf *config.Config) []*coverage.Source {
+	var (
+		res  []*coverage.Source
+		seen = make(map[string]bool)
+	)
+
+	for _, m := range conf.Modules {
+		if m.Dir != "" {
+			res = append(res, coverage.NewSource(filepath.Join(conf.Source, filepath.FromSlash(m.Dir)), m.Path))
+			seen[m.Path] = true
+		}
+	}
+
+	if src, err := coverage.FindSource(conf.Source); err == nil && !seen[src.ModulePath] {
+		res = append(res, src)
+	}
+
+	return res
+}
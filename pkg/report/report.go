@@ -1,10 +1,9 @@
 package report
 
 import (
-	"encoding/json"
 	"fmt"
 	"math"
-	"path/filepath"
+	"path"
 	"sort"
 	"strings"
 
@@ -13,45 +12,149 @@ import (
 	"github.com/willjunx/go-coverage-report/pkg/coverage"
 )
 
+const (
+	FormatMarkdown  = "markdown"
+	FormatJSON      = "json"
+	FormatCobertura = "cobertura"
+	FormatHTML      = "html"
+)
+
+// maxUncoveredLines limits the line ranges listed per file in the uncovered
+// lines of the markdown report.
+const maxUncoveredLines = 10
+
+// Formats lists the output formats supported by Render.
+var Formats = []string{FormatMarkdown, FormatJSON, FormatCobertura, FormatHTML}
+
 type Report struct {
 	Old, New        *coverage.Coverage
 	ChangedFiles    []string
 	ChangedPackages []string
-
-	PackageCoveragePass CoveragePass
-	FileCoveragePass    CoveragePass
-	TotalCoveragePass   bool
-
-	conf *config.Config
+	// PackageNames holds the name of the changed packages, keyed by import
+	// path. They are derived from the import paths, unless read from the
+	// source by a coverage.Packages resolver, see Options.Packages.
+	PackageNames map[string]string
+	// Changes holds the kind of change of each changed file, see ChangeOf.
+	Changes map[string]string
+	// Renames holds the previous name of the renamed files, keyed by their
+	// new name. Old uses the new names of these files.
+	Renames map[string]string `json:",omitempty"`
+
+	PackageCoveragePass   CoveragePass
+	FileCoveragePass      CoveragePass
+	DirectoryCoveragePass CoveragePass
+	BranchCoveragePass    CoveragePass
+	TotalCoveragePass     bool
+	// RiskPass checks the changed functions against the maximum risk.
+	RiskPass bool
+
+	// Risks holds the risk score of the changed functions, riskiest first,
+	// when enabled.
+	Risks []FunctionRisk `json:",omitempty"`
+
+	// FileBranches and PackageBranches hold the approximated branch
+	// coverage of the changed packages and their files, when enabled.
+	FileBranches    map[string]coverage.Branches `json:",omitempty"`
+	PackageBranches map[string]coverage.Branches `json:",omitempty"`
+
+	// Modules holds the coverage of each configured module, for reports
+	// spanning several modules.
+	Modules []ModuleCoverage `json:",omitempty"`
+
+	// Hotness holds the execution count analysis of the changed files, when
+	// enabled in the config and recorded by the profiles.
+	Hotness map[string]FileHotness `json:",omitempty"`
+
+	// Tree holds the coverage rolled up by module, directory, package and
+	// file, when enabled in the config.
+	Tree *TreeNode `json:",omitempty"`
+
+	// Warnings holds the invalid lines of the profiles skipped by lenient
+	// parsing.
+	Warnings []coverage.Diagnostic `json:",omitempty"`
+
+	conf    *config.Config
+	symbols config.Symbols
+	names   *config.DisplayNames
 }
 
+// New compares the coverage of the changed files, detecting the renamed files
+// by the similarity of their blocks when enabled by the config.
 func New(conf *config.Config, oldCov, newCov *coverage.Coverage, changedFiles []string) *Report {
+	renames := DetectRenames(oldCov, newCov, changedFiles, nil, conf.Renames.Similarity)
+	return NewWithRenames(conf, oldCov, newCov, changedFiles, renames)
+}
+
+// NewWithRenames is like New with the given renames, see DetectRenames. The
+// renamed files are compared against their previous selves, and their old
+// names are dropped from the changed files.
+func NewWithRenames(
+	conf *config.Config, oldCov, newCov *coverage.Coverage, changedFiles []string, renames map[string]string,
+) *Report {
+	if len(renames) > 0 {
+		oldCov = oldCov.Rename(renames)
+		changedFiles = withoutRenamed(changedFiles, renames)
+	}
+
 	sort.Strings(changedFiles)
 	curChangedPackages := changedPackages(changedFiles)
 
-	return &Report{
-		Old:                 oldCov,
-		New:                 newCov,
-		ChangedFiles:        changedFiles,
-		ChangedPackages:     curChangedPackages,
-		PackageCoveragePass: checkPackageCoverage(conf.Threshold.Package, newCov, curChangedPackages),
-		FileCoveragePass:    checkFileCoverage(conf.Threshold.File, newCov, changedFiles),
-		TotalCoveragePass:   isCoveragePassed(conf.Threshold.Total, newCov.Percent()),
-		conf:                conf,
+	r := &Report{
+		Old:                   oldCov,
+		New:                   newCov,
+		ChangedFiles:          changedFiles,
+		ChangedPackages:       curChangedPackages,
+		PackageNames:          coverage.NewPackages().Names(curChangedPackages),
+		Changes:               changes(oldCov, newCov, changedFiles, renames),
+		Renames:               renames,
+		PackageCoveragePass:   checkPackageCoverage(conf, newCov, curChangedPackages),
+		FileCoveragePass:      checkFileCoverage(conf, newCov, changedFiles),
+		DirectoryCoveragePass: checkDirectoryCoverage(conf, newCov, changedFiles),
+		TotalCoveragePass:     isCoveragePassed(conf.Threshold.Total, newCov.Percent()),
+		Modules:               moduleCoverages(conf, oldCov, newCov, changedFiles),
+		Warnings:              warnings(oldCov, newCov),
+		conf:                  conf,
+		symbols:               conf.Symbols.Resolve(),
+		names:                 displayNames(conf),
+	}
+
+	if hasBranches(conf) {
+		r.FileBranches, r.PackageBranches = branchCoverage(conf, newCov, curChangedPackages)
+	}
+
+	r.BranchCoveragePass = checkBranchCoverage(conf, r.FileBranches, changedFiles)
+
+	if hasRisk(conf) {
+		r.Risks = functionRisks(conf, oldCov, newCov, changedFiles)
+	}
+
+	r.RiskPass = checkRisk(conf.Risk.Max, r.Risks)
+
+	if conf.Hotness {
+		r.Hotness = hotness(oldCov, newCov, changedFiles)
 	}
+
+	if conf.Tree.Enabled {
+		r.Tree = buildTree(conf.RootPackage, oldCov, newCov, changedFiles, conf.Tree.Depth)
+	}
+
+	return r
 }
 
-func checkFileCoverage(threshold int, cov *coverage.Coverage, changedFiles []string) CoveragePass {
+// checkFileCoverage checks the changed files against the file threshold of
+// their module.
+func checkFileCoverage(conf *config.Config, cov *coverage.Coverage, changedFiles []string) CoveragePass {
 	var res = CoveragePass{
 		Value:  true,
 		Detail: make(map[string]bool),
 	}
 
-	if threshold <= 0 {
-		return res
-	}
-
 	for _, filename := range changedFiles {
+		threshold := conf.ThresholdOf(filename).File
+		if threshold <= 0 {
+			continue
+		}
+
 		fileCov, ok := cov.Files[filename]
 		if !ok {
 			continue
@@ -66,19 +169,26 @@ func checkFileCoverage(threshold int, cov *coverage.Coverage, changedFiles []str
 	return res
 }
 
-func checkPackageCoverage(threshold int, cov *coverage.Coverage, changedPackages []string) CoveragePass {
+// checkPackageCoverage checks the changed packages against the package
+// threshold of their module.
+func checkPackageCoverage(conf *config.Config, cov *coverage.Coverage, changedPackages []string) CoveragePass {
 	var res = CoveragePass{
 		Value:  true,
 		Detail: make(map[string]bool),
 	}
 
-	if threshold <= 0 {
+	if !hasThreshold(conf, func(t config.Threshold) int { return t.Package }) {
 		return res
 	}
 
 	packages := cov.ByPackage()
 
 	for _, pkg := range changedPackages {
+		threshold := conf.ThresholdOf(pkg).Package
+		if threshold <= 0 {
+			continue
+		}
+
 		pkgCov, ok := packages[pkg]
 		if !ok {
 			continue
@@ -93,6 +203,38 @@ func checkPackageCoverage(threshold int, cov *coverage.Coverage, changedPackages
 	return res
 }
 
+func changes(oldCov, newCov *coverage.Coverage, changedFiles []string, renames map[string]string) map[string]string {
+	res := make(map[string]string, len(changedFiles))
+
+	for _, file := range changedFiles {
+		if _, ok := renames[file]; ok {
+			res[file] = ChangeRenamed
+			continue
+		}
+
+		res[file] = ChangeOf(oldCov, newCov, file)
+	}
+
+	return res
+}
+
+func withoutRenamed(changedFiles []string, renames map[string]string) []string {
+	oldNames := make(map[string]bool, len(renames))
+	for _, oldName := range renames {
+		oldNames[oldName] = true
+	}
+
+	res := make([]string, 0, len(changedFiles))
+
+	for _, file := range changedFiles {
+		if !oldNames[file] {
+			res = append(res, file)
+		}
+	}
+
+	return res
+}
+
 func changedPackages(changedFiles []string) []string {
 	var (
 		res     = make([]string, 0)
@@ -100,7 +242,7 @@ func changedPackages(changedFiles []string) []string {
 	)
 
 	for _, file := range changedFiles {
-		pkg := filepath.Dir(file)
+		pkg := coverage.PackagePath(file)
 		if visited[pkg] {
 			continue
 		}
@@ -114,31 +256,125 @@ func changedPackages(changedFiles []string) []string {
 	return res
 }
 
+// displayNames returns the names shown for the files and packages, or nil
+// to show their import paths when the rules, checked by Config.Validate, are
+// invalid.
+func displayNames(conf *config.Config) *config.DisplayNames {
+	names, err := conf.DisplayNames()
+	if err != nil {
+		return nil
+	}
+
+	return names
+}
+
+// packageLabel shows the package name next to its display name, when the
+// name differs from the last element of the import path.
+func (r *Report) packageLabel(pkg string) string {
+	return packageLabel(r.names, r.PackageNames, pkg)
+}
+
+func packageLabel(names *config.DisplayNames, packageNames map[string]string, pkg string) string {
+	label := link(names.Name(pkg), names.PackageURL(pkg))
+
+	name, ok := packageNames[pkg]
+	if !ok || name == path.Base(pkg) {
+		return label
+	}
+
+	return fmt.Sprintf("%s (`%s`)", label, name)
+}
+
+// link renders a markdown link to url, or the label alone without url.
+func link(label, url string) string {
+	if url == "" {
+		return label
+	}
+
+	return fmt.Sprintf("[%s](%s)", label, url)
+}
+
+// addReportLink links the full report, if any.
+func addReportLink(report *strings.Builder, names *config.DisplayNames) {
+	if url := names.ReportURL(); url != "" {
+		_, _ = fmt.Fprintf(report, "[Full coverage report](%s)\n\n", url)
+	}
+}
+
+// Markdown renders the report as the markdown of a pull request comment,
+// shortened to fit the size limit of the comment, see config.Comment.
 func (r *Report) Markdown() string {
-	var (
-		report           = new(strings.Builder)
-		hasCheckCoverage = r.conf.Threshold.Package > 0
-	)
+	res := r.markdown()
+
+	if limit := r.conf.Comment.MaxSize(); limit > 0 && len(res) > limit {
+		return fitLimit(r.budgetedMarkdown(limit), limit)
+	}
+
+	return res
+}
+
+func (r *Report) markdown() string {
+	report := new(strings.Builder)
 
 	_, _ = fmt.Fprintln(report, r.Title())
+	_, _ = fmt.Fprintln(report, strings.Join(r.packagesTable(r.ChangedPackages), "\n"))
+
+	report.WriteString("\n")
+
+	addReportLink(report, r.names)
 
+	if len(r.Modules) > 0 {
+		r.addModules(report)
+	}
+
+	if r.Tree != nil {
+		r.addTree(report)
+	}
+
+	if r.conf.Risk.Top > 0 {
+		r.addRisks(report)
+	}
+
+	if len(r.Warnings) > 0 {
+		r.addWarnings(report)
+	}
+
+	r.addDetails(report)
+
+	return report.String()
+}
+
+// packagesTable returns the lines of the table of the packages: its header,
+// its separator and a row per package.
+func (r *Report) packagesTable(packages []string) []string {
 	var (
-		header    = "| Impacted Packages | Coverage Δ | :robot: |"
-		separator = "|-------------------|------------|---------|"
+		hasCheckCoverage = hasThreshold(r.conf, func(t config.Threshold) int { return t.Package })
+		header           = "| Impacted Packages | Coverage Δ |"
+		separator        = "|-------------------|------------|"
 	)
 
+	if r.hasTrend() {
+		header += fmt.Sprintf(" %s |", r.symbols.Trend)
+		separator += "---------|"
+	}
+
 	if hasCheckCoverage {
 		header += " Pass |"
 		separator += "------|"
 	}
 
-	_, _ = fmt.Fprintln(report, header)
-	_, _ = fmt.Fprintln(report, separator)
+	if r.PackageBranches != nil {
+		header += " Branches |"
+		separator += "----------|"
+	}
 
-	oldCovPkgs := r.Old.ByPackage()
-	newCovPkgs := r.New.ByPackage()
+	var (
+		lines      = []string{header, separator}
+		oldCovPkgs = r.Old.ByPackage()
+		newCovPkgs = r.New.ByPackage()
+	)
 
-	for _, pkg := range r.ChangedPackages {
+	for _, pkg := range packages {
 		var oldPercent, newPercent float64
 
 		if cov, ok := oldCovPkgs[pkg]; ok {
@@ -149,33 +385,55 @@ func (r *Report) Markdown() string {
 			newPercent = cov.Percent()
 		}
 
-		emoji, diffStr := emojiScore(newPercent, oldPercent)
+		symbol, diffStr := scoreSymbol(r.symbols, newPercent, oldPercent)
 
-		format := "| %s | %.2f%% (%s) | %s |"
-		args := []interface{}{pkg, newPercent, diffStr, emoji}
+		format := "| %s | %.2f%% (%s) |"
+		args := []interface{}{r.packageLabel(pkg), newPercent, diffStr}
+
+		if r.hasTrend() {
+			format += " %s |"
+
+			args = append(args, symbol)
+		}
 
 		if hasCheckCoverage {
 			format += " %s |"
 
-			args = append(args, emojiPass(r.PackageCoveragePass.Detail[pkg]))
+			args = append(args, passSymbol(r.symbols, r.PackageCoveragePass.Detail[pkg]))
 		}
 
-		_, _ = fmt.Fprintf(report, format+"\n", args...)
+		if r.PackageBranches != nil {
+			format += " %s |"
+
+			b, ok := r.PackageBranches[pkg]
+			args = append(args, branchLabel(r.symbols, b, ok, nil))
+		}
+
+		lines = append(lines, fmt.Sprintf(format, args...))
 	}
 
-	report.WriteString("\n")
-	r.addDetails(report)
+	return lines
+}
 
-	return report.String()
+// JSON returns the report as JSON, see WriteJSON.
+func (r *Report) JSON() (string, error) {
+	return renderJSON(r.WriteJSON)
 }
 
-func (r *Report) JSON() string {
-	data, err := json.MarshalIndent(r, "", "    ")
-	if err != nil {
-		panic(err) // should never happen
+// Render returns the report in the given output format.
+func (r *Report) Render(format string) (string, error) {
+	switch format {
+	case FormatMarkdown:
+		return r.Markdown(), nil
+	case FormatJSON:
+		return r.JSON()
+	case FormatCobertura:
+		return r.Cobertura()
+	case FormatHTML:
+		return r.HTML(), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %q", format)
 	}
-
-	return string(data)
 }
 
 func (r *Report) Title() string {
@@ -228,72 +486,148 @@ func (r *Report) addDetails(report *strings.Builder) {
 	_, _ = fmt.Fprintln(report, "<summary>Coverage by file</summary>")
 	_, _ = fmt.Fprintln(report)
 
-	var codeFiles, unitTestFiles []string
+	codeFiles, unitTestFiles := r.changedFilesByChange()
 
-	for _, f := range r.ChangedFiles {
-		if strings.HasSuffix(f, "_test.go") {
-			unitTestFiles = append(unitTestFiles, f)
-		} else {
-			codeFiles = append(codeFiles, f)
-		}
+	r.addCodeFileSections(report, codeFiles)
+
+	if r.names.HasLines() {
+		r.addUncoveredLines(report, codeFiles)
 	}
 
-	if len(codeFiles) > 0 {
-		r.addCodeFileDetails(report, codeFiles)
+	if len(r.Hotness) > 0 {
+		r.addHotness(report)
 	}
 
 	if len(unitTestFiles) > 0 {
 		r.addChangedTestFileDetails(report, unitTestFiles)
 	}
 
+	if r.New.ExcludedStmt > 0 {
+		r.addExcludedDetails(report)
+	}
+
 	_, _ = fmt.Fprint(report, "</details>")
 
-	if r.conf.Threshold.Total > 0 || r.conf.Threshold.File > 0 || r.conf.Threshold.Package > 0 {
+	if r.hasResult() {
 		_, _ = fmt.Fprintln(report)
 		r.addTotalCoverageResult(report)
 	}
 }
 
+// fileSections lists the sections of the changed files by kind of change,
+// in the order of the report.
+var fileSections = []struct{ change, title string }{
+	{ChangeAdded, "Added files"},
+	{ChangeModified, "Modified files"},
+	{ChangeRenamed, "Renamed files"},
+	{ChangeCoverageOnly, "Files with coverage changes only"},
+	{ChangeRemoved, "Removed files"},
+}
+
+// changedFilesByChange splits the changed files into the code files, keyed
+// by kind of change, and the unit test files.
+func (r *Report) changedFilesByChange() (codeFiles map[string][]string, unitTestFiles []string) {
+	codeFiles = make(map[string][]string)
+
+	for _, f := range r.ChangedFiles {
+		if strings.HasSuffix(f, "_test.go") {
+			unitTestFiles = append(unitTestFiles, f)
+		} else {
+			codeFiles[r.Changes[f]] = append(codeFiles[r.Changes[f]], f)
+		}
+	}
+
+	return codeFiles, unitTestFiles
+}
+
+func (r *Report) addCodeFileSections(report *strings.Builder, codeFiles map[string][]string) {
+	for _, section := range fileSections {
+		if files := codeFiles[section.change]; len(files) > 0 {
+			r.addCodeFileDetails(report, section.title, section.change, files)
+		}
+	}
+}
+
+// hasResult tells whether the report ends with the result of the checks.
+func (r *Report) hasResult() bool {
+	return hasResult(r.conf)
+}
+
+// hasResult tells whether the config checks the coverage, so that the
+// reports end with the result of the checks.
+func hasResult(conf *config.Config) bool {
+	return conf.Risk.Max > 0 ||
+		hasThreshold(conf, func(t config.Threshold) int { return max(t.Total, t.File, t.Package, t.Directory, t.Branch) })
+}
+
 func (r *Report) addTotalCoverageResult(report *strings.Builder) {
 	_, _ = fmt.Fprintln(report, "\n---")
 
-	pass := r.TotalCoveragePass && r.PackageCoveragePass.Value && r.FileCoveragePass.Value
+	result, symbol := "FAIL", r.symbols.Fail
+	if r.Passed() {
+		result, symbol = "PASS", r.symbols.Pass
+	}
 
-	_, _ = fmt.Fprintf(
-		report,
-		"### Coverage Result: %s %s",
-		emojiPass(pass),
-		func() string {
-			if pass {
-				return "PASS"
-			}
+	if symbol != "" {
+		result = symbol + " " + result
+	}
 
-			return "FAIL"
-		}(),
-	)
+	_, _ = fmt.Fprintf(report, "### Coverage Result: %s", result)
+}
+
+// Passed tells whether the new coverage passes every check of the config.
+func (r *Report) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures returns the checks the new coverage fails.
+func (r *Report) Failures() []string {
+	return failures(map[string]bool{
+		CheckTotal:     r.TotalCoveragePass,
+		CheckPackage:   r.PackageCoveragePass.Value,
+		CheckFile:      r.FileCoveragePass.Value,
+		CheckDirectory: r.DirectoryCoveragePass.Value,
+		CheckBranch:    r.BranchCoveragePass.Value,
+		CheckRisk:      r.RiskPass,
+		CheckModules:   modulesPass(r.Modules),
+	})
 }
 
-func (r *Report) addCodeFileDetails(report *strings.Builder, files []string) {
-	_, _ = fmt.Fprintln(report, "### Changed files")
+func (r *Report) addCodeFileDetails(report *strings.Builder, title, change string, files []string) {
+	_, _ = fmt.Fprintf(report, "### %s\n", title)
 	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintln(report, strings.Join(r.codeFilesTable(change, files), "\n"))
+}
 
+// codeFilesTable returns the lines of the table of the files of a kind of
+// change: its header, its separator and a row per file.
+func (r *Report) codeFilesTable(change string, files []string) []string {
 	var (
-		header    = "| Changed File | Coverage Δ | Total | Covered | Missed | :robot: |"
-		separator = "|--------------|------------|-------|---------|--------|---------|"
+		header    = "| Changed File | Coverage Δ | Total | Covered | Missed |"
+		separator = "|--------------|------------|-------|---------|--------|"
 	)
 
-	hasCheck := r.conf.Threshold.File > 0
+	if r.hasTrend() {
+		header += fmt.Sprintf(" %s |", r.symbols.Trend)
+		separator += "---------|"
+	}
+
+	// removed files are not checked against the file threshold
+	hasCheck := change != ChangeRemoved && hasThreshold(r.conf, func(t config.Threshold) int { return t.File })
 	if hasCheck {
 		header += " Pass |"
 		separator += "------|"
 	}
 
-	_, _ = fmt.Fprintln(report, header)
-	_, _ = fmt.Fprintln(report, separator)
+	hasBranches := change != ChangeRemoved && r.FileBranches != nil
+	if hasBranches {
+		header += " Branches |"
+		separator += "----------|"
+	}
 
-	for _, name := range files {
-		fullPath := filepath.Join(r.conf.RootPackage, name)
+	lines := []string{header, separator}
 
+	for _, name := range files {
 		oldProfile, newProfile := r.Old.Files[name], r.New.Files[name]
 		oldPercent, newPercent := oldProfile.CoveragePercent(), newProfile.CoveragePercent()
 
@@ -310,93 +644,156 @@ func (r *Report) addCodeFileDetails(report *strings.Builder, files []string) {
 			}
 		}
 
-		emoji, diffStr := emojiScore(newPercent, oldPercent)
+		symbol, diffStr := scoreSymbol(r.symbols, newPercent, oldPercent)
 
-		format := "| %s | %.2f%% (%s) | %s | %s | %s | %s |"
+		// removed files no longer exist at the new commit
+		label := r.names.Name(name)
+		if change != ChangeRemoved {
+			label = link(label, r.names.FileURL(name))
+		}
+
+		if oldName, ok := r.Renames[name]; ok {
+			label += fmt.Sprintf(" (from %s)", r.names.Name(oldName))
+		}
+
+		format := "| %s | %.2f%% (%s) | %s | %s | %s |"
 		args := []any{
-			fullPath,
+			label,
 			newPercent, diffStr,
 			valueWithDelta(oldProfile.GetTotal(), newProfile.GetTotal()),
 			valueWithDelta(oldProfile.GetCovered(), newProfile.GetCovered()),
 			valueWithDelta(oldProfile.GetMissed(), newProfile.GetMissed()),
-			emoji,
+		}
+
+		if r.hasTrend() {
+			format += " %s |"
+
+			args = append(args, symbol)
 		}
 
 		if hasCheck {
 			format += " %s |"
 
-			args = append(args, emojiPass(r.FileCoveragePass.Detail[fullPath]))
+			args = append(args, passSymbol(r.symbols, r.FileCoveragePass.Detail[name]))
+		}
+
+		if hasBranches {
+			format += " %s |"
+
+			var pass *bool
+			if v, ok := r.BranchCoveragePass.Detail[name]; ok {
+				pass = &v
+			}
+
+			b, ok := r.FileBranches[name]
+			args = append(args, branchLabel(r.symbols, b, ok, pass))
 		}
 
-		_, _ = fmt.Fprintf(report, format+"\n", args...)
+		lines = append(lines, fmt.Sprintf(format, args...))
 	}
+
+	return lines
 }
 
-func (r *Report) addChangedTestFileDetails(report *strings.Builder, files []string) {
-	_, _ = fmt.Fprintln(report, "### Changed unit test files")
-	_, _ = fmt.Fprintln(report)
+// addUncoveredLines lists the uncovered lines of the changed files, linked
+// to their source.
+func (r *Report) addUncoveredLines(report *strings.Builder, codeFiles map[string][]string) {
+	var files []string
+
+	for change, names := range codeFiles {
+		if change != ChangeRemoved {
+			files = append(files, names...)
+		}
+	}
+
+	sort.Strings(files)
+
+	var lines []string
 
 	for _, name := range files {
-		_, _ = fmt.Fprintf(report, "- %s\n", name)
+		ranges := r.New.Files[name].UncoveredLines()
+		if len(ranges) == 0 {
+			continue
+		}
+
+		links := make([]string, 0, maxUncoveredLines+1)
+
+		for i, l := range ranges {
+			if i == maxUncoveredLines {
+				links = append(links, fmt.Sprintf("… and %d more", len(ranges)-maxUncoveredLines))
+				break
+			}
+
+			links = append(links, link(l.String(), r.names.LinesURL(name, l.Start, l.End)))
+		}
+
+		lines = append(lines, fmt.Sprintf("- %s: %s", link(r.names.Name(name), r.names.FileURL(name)), strings.Join(links, ", ")))
 	}
 
+	if len(lines) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintln(report, "### Uncovered lines")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintln(report, strings.Join(lines, "\n"))
 	_, _ = fmt.Fprintln(report)
 }
 
-func roundFloat(val float64, precision int) float64 {
-	if val == 0 {
-		return 0
-	}
+func (r *Report) addChangedTestFileDetails(report *strings.Builder, files []string) {
+	_, _ = fmt.Fprintln(report, "### Changed unit test files")
+	_, _ = fmt.Fprintln(report)
 
-	pow := math.Pow10(precision)
+	for _, name := range files {
+		_, _ = fmt.Fprintf(report, "- %s\n", link(r.names.Name(name), r.names.FileURL(name)))
+	}
 
-	return math.Round(pow*val) / pow
+	_, _ = fmt.Fprintln(report)
 }
 
-func emojiScore(newPercent, oldPercent float64) (emoji, diffStr string) {
-	diff := newPercent - oldPercent
-	diffStr = fmt.Sprintf("**%+.2f%%**", diff)
+func (r *Report) addExcludedDetails(report *strings.Builder) {
+	var generatedFiles, generatedStmt int
 
-	switch {
-	case diff < -50:
-		emoji = strings.Repeat(":skull: ", 5)
-	case diff < -10:
-		emoji = strings.Repeat(":skull: ", int(-diff/10))
-	case diff < 0:
-		emoji = ":thumbsdown:"
-	case diff == 0:
-		emoji = ""
-		diffStr = "ø"
-	case diff > 20:
-		emoji = ":star2:"
-	case diff > 10:
-		emoji = ":tada:"
-	case diff > 0:
-		emoji = ":thumbsup:"
+	for _, f := range r.New.ExcludedFiles {
+		if f.Reason == coverage.ReasonGenerated {
+			generatedFiles++
+			generatedStmt += f.Stmt
+		}
 	}
 
-	return emoji, diffStr
+	_, _ = fmt.Fprintln(report, "### Excluded code")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintf(
+		report,
+		"**%d** statements are excluded from the coverage: %d in %d generated files, %d by `%s` directives.\n",
+		r.New.ExcludedStmt, generatedStmt, generatedFiles, r.New.ExcludedStmt-generatedStmt, coverage.IgnoreDirective,
+	)
+	_, _ = fmt.Fprintln(report)
 }
 
-func emojiPass(val bool) string {
-	if val {
-		return ":white_check_mark:"
+func roundFloat(val float64, precision int) float64 {
+	if val == 0 {
+		return 0
 	}
 
-	return ":negative_squared_cross_mark:"
+	pow := math.Pow10(precision)
+
+	return math.Round(pow*val) / pow
 }
 
-func (r *Report) TrimPrefix(prefix string) {
-	for i, name := range r.ChangedPackages {
-		r.ChangedPackages[i] = coverage.TrimPrefix(name, prefix)
+// hasThreshold reports whether a threshold is set, globally or for a module.
+func hasThreshold(conf *config.Config, get func(t config.Threshold) int) bool {
+	if get(conf.Threshold) > 0 {
+		return true
 	}
 
-	for i, name := range r.ChangedFiles {
-		r.ChangedFiles[i] = coverage.TrimPrefix(name, prefix)
+	for _, m := range conf.Modules {
+		if get(conf.ThresholdOf(m.Path)) > 0 {
+			return true
+		}
 	}
 
-	r.Old.TrimPrefix(prefix)
-	r.New.TrimPrefix(prefix)
+	return false
 }
 
 func isCoveragePassed(threshold int, cov float64) bool {
@@ -1,6 +1,12 @@
 package report_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -39,12 +45,12 @@ var _ = Describe("Report", func() {
 
 <summary>Coverage by file</summary>
 
-### Changed files
+### Modified files
 
 | Changed File | Coverage Δ | Total | Covered | Missed | :robot: |
 |--------------|------------|-------|---------|--------|---------|
 | github.com/username/prioqueue/foo/bar/baz.go | 0.00% (ø) | 0 | 0 | 0 |  |
-| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull:  |
+| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull: |
 </details>`
 
 			Expect(actual).To(Equal(expected))
@@ -120,12 +126,12 @@ var _ = Describe("Report", func() {
 
 <summary>Coverage by file</summary>
 
-### Changed files
+### Modified files
 
 | Changed File | Coverage Δ | Total | Covered | Missed | :robot: |
 |--------------|------------|-------|---------|--------|---------|
 | github.com/username/prioqueue/foo/bar/baz.go | 0.00% (ø) | 0 | 0 | 0 |  |
-| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull:  |
+| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull: |
 </details>
 
 ---
@@ -157,12 +163,12 @@ var _ = Describe("Report", func() {
 
 <summary>Coverage by file</summary>
 
-### Changed files
+### Modified files
 
 | Changed File | Coverage Δ | Total | Covered | Missed | :robot: |
 |--------------|------------|-------|---------|--------|---------|
 | github.com/username/prioqueue/foo/bar/baz.go | 0.00% (ø) | 0 | 0 | 0 |  |
-| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull:  |
+| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull: |
 </details>
 
 ---
@@ -173,6 +179,80 @@ var _ = Describe("Report", func() {
 				})
 			})
 
+			When("with ascii symbols", func() {
+				It("Should return correctly", func() {
+					cfg := config.Default
+					cfg.Threshold.File = 95
+					cfg.Symbols = config.Symbols{Preset: config.PresetASCII}
+
+					report := report.New(&cfg, oldCov, newCov, changedFiles[1:])
+					actual := report.Markdown()
+
+					expected := `## Coverage Percentage 90.20%
+### Merging this branch will **decrease** overall coverage
+
+| Impacted Packages | Coverage Δ | Trend |
+|-------------------|------------|---------|
+| github.com/username/prioqueue | 90.20% (**-9.80%**) | - |
+
+---
+
+<details>
+
+<summary>Coverage by file</summary>
+
+### Modified files
+
+| Changed File | Coverage Δ | Total | Covered | Missed | Trend | Pass |
+|--------------|------------|-------|---------|--------|---------|------|
+| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | - | !! |
+</details>
+
+---
+### Coverage Result: !! FAIL`
+
+					Expect(actual).To(Equal(expected))
+				})
+			})
+
+			When("without symbols", func() {
+				It("Should return correctly", func() {
+					cfg := config.Default
+					cfg.Threshold.Package = 50
+					cfg.Symbols = config.Symbols{Preset: config.PresetNone}
+
+					report := report.New(&cfg, oldCov, newCov, changedFiles)
+					actual := report.Markdown()
+
+					expected := `## Coverage Percentage 90.20%
+### Merging this branch will **decrease** overall coverage
+
+| Impacted Packages | Coverage Δ | Pass |
+|-------------------|------------|------|
+| github.com/username/prioqueue | 90.20% (**-9.80%**) | PASS |
+| github.com/username/prioqueue/foo/bar | 0.00% (=) | FAIL |
+
+---
+
+<details>
+
+<summary>Coverage by file</summary>
+
+### Modified files
+
+| Changed File | Coverage Δ | Total | Covered | Missed |
+|--------------|------------|-------|---------|--------|
+| github.com/username/prioqueue/foo/bar/baz.go | 0.00% (=) | 0 | 0 | 0 |
+| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) |
+</details>
+
+---
+### Coverage Result: PASS`
+
+					Expect(actual).To(Equal(expected))
+				})
+			})
+
 			When("with package threshold", func() {
 				When("Success", func() {
 					It("Should return correctly", func() {
@@ -195,11 +275,11 @@ var _ = Describe("Report", func() {
 
 <summary>Coverage by file</summary>
 
-### Changed files
+### Modified files
 
 | Changed File | Coverage Δ | Total | Covered | Missed | :robot: |
 |--------------|------------|-------|---------|--------|---------|
-| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull:  |
+| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull: |
 </details>
 
 ---
@@ -230,11 +310,11 @@ var _ = Describe("Report", func() {
 
 <summary>Coverage by file</summary>
 
-### Changed files
+### Modified files
 
 | Changed File | Coverage Δ | Total | Covered | Missed | :robot: |
 |--------------|------------|-------|---------|--------|---------|
-| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull:  |
+| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull: |
 </details>
 
 ---
@@ -267,11 +347,11 @@ var _ = Describe("Report", func() {
 
 <summary>Coverage by file</summary>
 
-### Changed files
+### Modified files
 
 | Changed File | Coverage Δ | Total | Covered | Missed | :robot: | Pass |
 |--------------|------------|-------|---------|--------|---------|------|
-| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull:  | :white_check_mark: |
+| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull: | :white_check_mark: |
 </details>
 
 ---
@@ -302,11 +382,11 @@ var _ = Describe("Report", func() {
 
 <summary>Coverage by file</summary>
 
-### Changed files
+### Modified files
 
 | Changed File | Coverage Δ | Total | Covered | Missed | :robot: | Pass |
 |--------------|------------|-------|---------|--------|---------|------|
-| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull:  | :negative_squared_cross_mark: |
+| github.com/username/prioqueue/min_heap.go | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull: | :negative_squared_cross_mark: |
 </details>
 
 ---
@@ -318,4 +398,867 @@ var _ = Describe("Report", func() {
 			})
 		})
 	})
+
+	Context("Cobertura", func() {
+		It("Should return correctly", func() {
+			oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			rep := report.New(&config.Default, oldCov, newCov, nil)
+
+			actual, err := rep.Render(report.FormatCobertura)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(HavePrefix(`<?xml version="1.0" encoding="UTF-8"?>`))
+			Expect(actual).To(ContainSubstring(`<coverage line-rate="0.904" branch-rate="0" lines-covered="160" lines-valid="177"`))
+			Expect(actual).To(ContainSubstring(`<class name="min_heap.go" filename="github.com/username/prioqueue/min_heap.go"`))
+		})
+	})
+	Context("Tree", func() {
+		oldCov := coverage.NewCoverage([]coverage.Profile{
+			{FileName: "example.com/mod/internal/a/a.go", TotalStmt: 10, CoveredStmt: 8, MissedStmt: 2},
+			{FileName: "example.com/mod/internal/c/c.go", TotalStmt: 4, CoveredStmt: 4},
+		})
+		newCov := coverage.NewCoverage([]coverage.Profile{
+			{FileName: "example.com/mod/internal/a/a.go", TotalStmt: 10, CoveredStmt: 5, MissedStmt: 5},
+			{FileName: "example.com/mod/internal/a/b/b.go", TotalStmt: 10, CoveredStmt: 10},
+			{FileName: "example.com/mod/internal/c/c.go", TotalStmt: 4, CoveredStmt: 4},
+		})
+		changedFiles := []string{"example.com/mod/internal/a/a.go", "example.com/mod/internal/a/b/b.go"}
+
+		It("Should roll up the changed levels", func() {
+			cfg := config.Default
+			cfg.RootPackage = "example.com/mod"
+			cfg.Tree = config.Tree{Enabled: true, Depth: 2}
+			cfg.Threshold.Directory = 81
+
+			rep := report.New(&cfg, oldCov, newCov, changedFiles)
+			Expect(rep.DirectoryCoveragePass).To(Equal(report.CoveragePass{
+				Value: false,
+				Detail: map[string]bool{
+					"example.com/mod/internal":     false,
+					"example.com/mod/internal/a":   false,
+					"example.com/mod/internal/a/b": true,
+				},
+			}))
+
+			Expect(rep.Markdown()).To(ContainSubstring(`<details>
+<summary>Coverage tree</summary>
+
+<ul>
+<li><details open><summary><code>example.com/mod</code> 79.17% (-6.55%) :thumbsdown:</summary>
+<ul>
+<li><details><summary><code>internal</code> 79.17% (-6.55%) :thumbsdown: :negative_squared_cross_mark:</summary>
+<ul>
+<li><code>a</code> 75.00% (-5.00%) :thumbsdown: :negative_squared_cross_mark:</li>
+</ul>
+</details></li>
+</ul>
+</details></li>
+</ul>
+</details>
+`))
+		})
+
+		It("Should keep the removed files", func() {
+			cfg := config.Default
+			cfg.RootPackage = "example.com/mod"
+			cfg.Tree = config.Tree{Enabled: true}
+
+			removed := coverage.NewCoverage([]coverage.Profile{newCov.Files["example.com/mod/internal/a/a.go"]})
+			rep := report.New(&cfg, oldCov, removed, []string{"example.com/mod/internal/c/c.go"})
+			Expect(rep.Markdown()).To(ContainSubstring("<li><details><summary><code>c</code> 0.00% (-100.00%)"))
+			Expect(rep.Markdown()).To(ContainSubstring("<li><code>c.go</code> 0.00% (-100.00%)"))
+		})
+
+		It("Should render the tree as HTML", func() {
+			cfg := config.Default
+			cfg.RootPackage = "example.com/mod"
+
+			page, err := report.New(&cfg, oldCov, newCov, changedFiles).Render(report.FormatHTML)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page).To(HavePrefix("<!DOCTYPE html>"))
+			Expect(page).To(ContainSubstring("<h1>Coverage Percentage 79.17%</h1>"))
+			Expect(page).To(ContainSubstring("<li><code>a.go</code> 50.00% (-30.00%) :skull: :skull: :skull:</li>"))
+			Expect(page).To(HaveSuffix("</html>"))
+
+			page, err = report.NewSummary(&cfg, newCov).Render(report.FormatHTML)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page).To(ContainSubstring("<li><code>c.go</code> 100.00%</li>"))
+		})
+	})
+
+	Context("Modules", func() {
+		oldCov := coverage.NewCoverage([]coverage.Profile{
+			{FileName: "example.com/mono/api/api.go", TotalStmt: 10, CoveredStmt: 8, MissedStmt: 2},
+			{FileName: "example.com/mono/tools/lint/lint.go", TotalStmt: 10, CoveredStmt: 5, MissedStmt: 5},
+		})
+		newCov := coverage.NewCoverage([]coverage.Profile{
+			{FileName: "example.com/mono/api/api.go", TotalStmt: 10, CoveredStmt: 9, MissedStmt: 1},
+			{FileName: "example.com/mono/tools/lint/lint.go", TotalStmt: 10, CoveredStmt: 6, MissedStmt: 4},
+			{FileName: "example.com/mono/tools/lint/rules.go", TotalStmt: 10, CoveredStmt: 6, MissedStmt: 4},
+		})
+		changedFiles := []string{
+			"example.com/mono/api/api.go",
+			"example.com/mono/tools/lint/lint.go",
+			"example.com/mono/tools/lint/rules.go",
+		}
+
+		It("Should report the coverage of each module", func() {
+			cfg := config.Default
+			cfg.Threshold.Total = 60
+			cfg.Modules = []config.Module{
+				{Path: "example.com/mono/tools/lint", Threshold: config.ModuleThreshold{Total: percent(70)}},
+				{Path: "example.com/mono/api"},
+				{Path: "example.com/mono/web"},
+			}
+
+			rep := report.New(&cfg, oldCov, newCov, changedFiles)
+			Expect(rep.TotalCoveragePass).To(BeTrue())
+			Expect(rep.Modules).To(Equal([]report.ModuleCoverage{
+				{
+					Path: "example.com/mono/api", OldPercent: 80, NewPercent: 90,
+					TotalStmt: 10, CoveredStmt: 9, MissedStmt: 1, ChangedFiles: 1, TotalCoveragePass: true,
+				},
+				{
+					Path: "example.com/mono/tools/lint", OldPercent: 50, NewPercent: 60,
+					TotalStmt: 20, CoveredStmt: 12, MissedStmt: 8, ChangedFiles: 2, TotalCoveragePass: false,
+				},
+			}))
+
+			markdown := rep.Markdown()
+			Expect(markdown).To(ContainSubstring(`| Module | Coverage Δ | Changed Files | :robot: | Pass |
+|--------|------------|---------------|---------|------|
+| example.com/mono/api | 90.00% (**+10.00%**) | 1 | :thumbsup: | :white_check_mark: |
+| example.com/mono/tools/lint | 60.00% (**+10.00%**) | 2 | :thumbsup: | :negative_squared_cross_mark: |
+`))
+			Expect(markdown).To(HaveSuffix("### Coverage Result: :negative_squared_cross_mark: FAIL"))
+		})
+
+		It("Should let a module disable a global threshold", func() {
+			cfg := config.Default
+			cfg.Threshold.File = 80
+			cfg.Modules = []config.Module{{Path: "example.com/mono/tools/lint", Threshold: config.ModuleThreshold{File: percent(0)}}}
+
+			rep := report.New(&cfg, oldCov, newCov, changedFiles)
+			Expect(rep.FileCoveragePass.Detail).To(Equal(map[string]bool{"example.com/mono/api/api.go": true}))
+			Expect(rep.Failures()).To(BeEmpty())
+		})
+	})
+
+	Context("Changes", func() {
+		It("Should render each kind of change in its own section", func() {
+			oldCov := coverage.NewCoverage([]coverage.Profile{
+				{FileName: "example.com/mod/a/tested.go", TotalStmt: 4, CoveredStmt: 2, MissedStmt: 2},
+				{FileName: "example.com/mod/b/removed.go", TotalStmt: 4, CoveredStmt: 4},
+			})
+			newCov := coverage.NewCoverage([]coverage.Profile{
+				{FileName: "example.com/mod/a/tested.go", TotalStmt: 4, CoveredStmt: 4},
+				{FileName: "example.com/mod/a/added.go", TotalStmt: 2, CoveredStmt: 1, MissedStmt: 1},
+			})
+			changedFiles := []string{"example.com/mod/a/tested.go", "example.com/mod/a/added.go", "example.com/mod/b/removed.go"}
+
+			cfg := config.Default
+			cfg.Threshold.File = 50
+
+			rep := report.New(&cfg, oldCov, newCov, changedFiles)
+			Expect(rep.Changes).To(Equal(map[string]string{
+				"example.com/mod/a/added.go":   report.ChangeAdded,
+				"example.com/mod/a/tested.go":  report.ChangeCoverageOnly,
+				"example.com/mod/b/removed.go": report.ChangeRemoved,
+			}))
+			Expect(rep.JSON()).To(ContainSubstring(`"example.com/mod/b/removed.go": "removed"`))
+
+			Expect(rep.Markdown()).To(ContainSubstring(`<summary>Coverage by file</summary>
+
+### Added files
+
+| Changed File | Coverage Δ | Total | Covered | Missed | :robot: | Pass |
+|--------------|------------|-------|---------|--------|---------|------|
+| example.com/mod/a/added.go | 50.00% (**+50.00%**) | 2 (+2) | 1 (+1) | 1 (+1) | :star2: | :white_check_mark: |
+### Files with coverage changes only
+
+| Changed File | Coverage Δ | Total | Covered | Missed | :robot: | Pass |
+|--------------|------------|-------|---------|--------|---------|------|
+| example.com/mod/a/tested.go | 100.00% (**+50.00%**) | 4 | 4 (+2) | 0 (-2) | :star2: | :white_check_mark: |
+### Removed files
+
+| Changed File | Coverage Δ | Total | Covered | Missed | :robot: |
+|--------------|------------|-------|---------|--------|---------|
+| example.com/mod/b/removed.go | 0.00% (**-100.00%**) | 0 (-4) | 0 (-4) | 0 | :skull: :skull: :skull: :skull: :skull: |
+</details>`))
+		})
+	})
+
+	Context("Renames", func() {
+		It("Should compare moved files against their previous selves", func() {
+			blocks := []coverage.ProfileBlock{
+				{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 2, NumStmt: 1, ExecCount: 1},
+				{StartLine: 7, StartCol: 30, EndLine: 12, EndCol: 2, NumStmt: 3},
+			}
+
+			oldCov := coverage.NewCoverage([]coverage.Profile{
+				{FileName: "example.com/mod/a/moved.go", Blocks: blocks, TotalStmt: 4, CoveredStmt: 1, MissedStmt: 3},
+			})
+			newCov := coverage.NewCoverage([]coverage.Profile{
+				{FileName: "example.com/mod/b/moved.go", Blocks: blocks, TotalStmt: 4, CoveredStmt: 1, MissedStmt: 3},
+			})
+
+			cfg := config.Default
+			cfg.Renames.Similarity = 80
+
+			rep := report.New(&cfg, oldCov, newCov, report.GetChangedFiles(oldCov, newCov, nil))
+			Expect(rep.ChangedFiles).To(Equal([]string{"example.com/mod/b/moved.go"}))
+			Expect(rep.Changes).To(Equal(map[string]string{"example.com/mod/b/moved.go": report.ChangeRenamed}))
+			Expect(rep.Renames).To(Equal(map[string]string{"example.com/mod/b/moved.go": "example.com/mod/a/moved.go"}))
+
+			Expect(rep.Markdown()).To(ContainSubstring(`| example.com/mod/b | 25.00% (ø) |  |
+
+---
+
+<details>
+
+<summary>Coverage by file</summary>
+
+### Renamed files
+
+| Changed File | Coverage Δ | Total | Covered | Missed | :robot: |
+|--------------|------------|-------|---------|--------|---------|
+| example.com/mod/b/moved.go (from example.com/mod/a/moved.go) | 25.00% (ø) | 4 | 1 | 3 |  |
+`))
+		})
+
+		It("Should not detect renames by default", func() {
+			oldCov := coverage.NewCoverage([]coverage.Profile{{FileName: "example.com/mod/a/moved.go", TotalStmt: 1}})
+			newCov := coverage.NewCoverage([]coverage.Profile{{FileName: "example.com/mod/b/moved.go", TotalStmt: 1}})
+
+			rep := report.New(&config.Default, oldCov, newCov, report.GetChangedFiles(oldCov, newCov, nil))
+			Expect(rep.Renames).To(BeEmpty())
+			Expect(rep.ChangedFiles).To(ConsistOf("example.com/mod/a/moved.go", "example.com/mod/b/moved.go"))
+		})
+	})
+
+	Context("Hotness", func() {
+		It("Should analyze the execution counts of the changed files", func() {
+			oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Hotness = true
+
+			rep := report.New(&cfg, oldCov, newCov, []string{"github.com/username/prioqueue/min_heap.go"})
+			Expect(rep.Hotness).To(HaveKey("github.com/username/prioqueue/min_heap.go"))
+			Expect(rep.JSON()).To(ContainSubstring(`"OnceDelta": `))
+
+			Expect(rep.Markdown()).To(ContainSubstring(`### Execution counts
+
+| File | Once | 2-9 | 10-99 | 100+ | Blocks ↑ / ↓ | Lines executed once |
+|------|------|-----|-------|------|--------------|---------------------|
+| github.com/username/prioqueue/min_heap.go | 4 | 4 | 34 | 0 | 0 / 2 (2 lost) | 84-86, 91-93, 98-101 |
+`))
+		})
+
+		It("Should be disabled by default", func() {
+			oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			rep := report.New(&config.Default, oldCov, newCov, []string{"github.com/username/prioqueue/min_heap.go"})
+			Expect(rep.Hotness).To(BeNil())
+			Expect(rep.Markdown()).NotTo(ContainSubstring("### Execution counts"))
+		})
+	})
+
+	Context("Failures", func() {
+		It("Should list the failed checks", func() {
+			oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Threshold = config.Threshold{Total: 95, File: 90}
+
+			rep := report.New(&cfg, oldCov, newCov, []string{"github.com/username/prioqueue/min_heap.go"})
+			Expect(rep.Failures()).To(Equal([]string{report.CheckTotal, report.CheckFile}))
+			Expect(rep.Passed()).To(BeFalse())
+
+			rep = report.New(&config.Default, oldCov, newCov, []string{"github.com/username/prioqueue/min_heap.go"})
+			Expect(rep.Failures()).To(BeEmpty())
+			Expect(rep.Passed()).To(BeTrue())
+		})
+	})
+
+	Context("Summary", func() {
+		It("Should check every package of a single profile", func() {
+			cov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Trim = "github.com/username"
+			cfg.Threshold = config.Threshold{Total: 90, Package: 95, File: 90}
+
+			summary := report.NewSummary(&cfg, cov)
+			Expect(summary.Packages).To(Equal([]string{"github.com/username/prioqueue"}))
+			Expect(summary.Failures()).To(Equal([]string{report.CheckPackage, report.CheckFile}))
+
+			Expect(summary.Markdown()).To(Equal(`## Coverage Percentage 90.20%
+### Coverage without a baseline to compare with
+
+| Packages | Coverage | Total | Covered | Missed | Pass |
+|----------|----------|-------|---------|--------|------|
+| prioqueue | 90.20% | 102 | 92 | 10 | :negative_squared_cross_mark: |
+
+---
+
+<details>
+
+<summary>Coverage by file</summary>
+
+| Files | Coverage | Total | Covered | Missed | Pass |
+|-------|----------|-------|---------|--------|------|
+| prioqueue/max_heap.go | 100.00% | 50 | 50 | 0 | :white_check_mark: |
+| prioqueue/min_heap.go | 80.77% | 52 | 42 | 10 | :negative_squared_cross_mark: |
+
+</details>
+
+---
+### Coverage Result: :negative_squared_cross_mark: FAIL`))
+			Expect(cov.Files).To(HaveKey("github.com/username/prioqueue/min_heap.go"), "the coverage must not be trimmed")
+
+			content, err := summary.Render(report.FormatCobertura)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(ContainSubstring(`<package name="prioqueue"`))
+		})
+
+		It("Should check the total threshold of each module", func() {
+			cov := coverage.NewCoverage([]coverage.Profile{
+				{FileName: "example.com/mono/api/api.go", TotalStmt: 10, CoveredStmt: 9, MissedStmt: 1},
+				{FileName: "example.com/mono/tools/lint/lint.go", TotalStmt: 10, CoveredStmt: 6, MissedStmt: 4},
+			})
+
+			cfg := config.Default
+			cfg.Modules = []config.Module{
+				{Path: "example.com/mono/tools/lint", Threshold: config.ModuleThreshold{Total: percent(70)}},
+				{Path: "example.com/mono/api"},
+			}
+
+			summary := report.NewSummary(&cfg, cov)
+			Expect(summary.Modules).To(HaveLen(2))
+			Expect(summary.Failures()).To(Equal([]string{report.CheckModules}))
+
+			markdown := summary.Markdown()
+			Expect(markdown).To(ContainSubstring(`| Modules | Coverage | Total | Covered | Missed | Pass |
+|---------|----------|-------|---------|--------|------|
+| example.com/mono/api | 90.00% | 10 | 9 | 1 | :white_check_mark: |
+| example.com/mono/tools/lint | 60.00% | 10 | 6 | 4 | :negative_squared_cross_mark: |
+`))
+			Expect(markdown).To(HaveSuffix("### Coverage Result: :negative_squared_cross_mark: FAIL"))
+		})
+
+		It("Should check the risk of every function", func() {
+			cov, err := coverage.NewCoverageFromFile("../coverage/testdata/04-branch-count-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Source = "../coverage/testdata/source"
+			cfg.Risk = config.Risk{Top: 1, Max: 3.1}
+
+			summary := report.NewSummary(&cfg, cov)
+			Expect(summary.Risks).To(HaveLen(5))
+			Expect(summary.Failures()).To(Equal([]string{report.CheckRisk}))
+			Expect(summary.Markdown()).To(ContainSubstring("| `Kind` | example.com/source/branch/branch.go:26 | 3 | 75.00% (3/4) | 3.14 :negative_squared_cross_mark: |"))
+		})
+
+		It("Should check the branches of every file", func() {
+			cov, err := coverage.NewCoverageFromFile("../coverage/testdata/04-branch-count-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Source = "../coverage/testdata/source"
+			cfg.RootPackage = "example.com/source"
+			cfg.Threshold.Branch = 80
+
+			summary := report.NewSummary(&cfg, cov)
+			Expect(summary.Failures()).To(Equal([]string{report.CheckBranch}))
+			Expect(summary.Markdown()).To(ContainSubstring("| 73.33% (11/15) :negative_squared_cross_mark: |"))
+		})
+	})
+
+	Context("Warnings", func() {
+		It("Should list the lines skipped by lenient parsing", func() {
+			oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err := coverage.NewCoverageFromFilesWithOptions(
+				coverage.ParseOptions{Lenient: true}, "testdata/01-new-coverage.txt", "../coverage/testdata/05-invalid-coverage.txt",
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			rep := report.New(&config.Default, oldCov, newCov, []string{"github.com/username/prioqueue/min_heap.go"})
+			Expect(rep.Warnings).To(HaveLen(1))
+			Expect(rep.JSON()).To(ContainSubstring(`"Reason": "bad format"`))
+
+			Expect(rep.Markdown()).To(ContainSubstring("### Warnings\n\n" +
+				"**1** invalid lines of the coverage profiles were skipped:\n\n" +
+				"- `../coverage/testdata/05-invalid-coverage.txt:3: example.com/app/main.go: bad format: " +
+				"couldn't parse \"EndCol\": strconv.Atoi: parsing \"x\": invalid syntax`\n"))
+		})
+
+		It("Should escape the backticks and shorten the long lines", func() {
+			oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err := coverage.NewCoverageFromReader(strings.NewReader("mode: set\n"+
+				"example.com/app/main.go:1.1,2.2 1 x`y\n"+
+				"example.com/app/"+strings.Repeat("a", 300)+".go:1.1\n",
+			), coverage.ParseOptions{Lenient: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(newCov.Diagnostics).To(HaveLen(2))
+
+			markdown := report.New(&config.Default, oldCov, newCov, []string{"example.com/app/main.go"}).Markdown()
+			Expect(markdown).To(MatchRegexp("- `` line 2: example.com/app/main.go: [^\n]*x`y[^\n]* ``\n"))
+			Expect(markdown).To(ContainSubstring("- `line 3: example.com/app/" + strings.Repeat("a", 103) + "…: "))
+			Expect(markdown).NotTo(ContainSubstring(strings.Repeat("a", 121)))
+		})
+
+		It("Should list the lines skipped in the summary", func() {
+			cov, err := coverage.NewCoverageFromFilesWithOptions(
+				coverage.ParseOptions{Lenient: true}, "testdata/01-new-coverage.txt", "../coverage/testdata/05-invalid-coverage.txt",
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			summary := report.NewSummary(&config.Default, cov)
+			Expect(summary.Warnings).To(HaveLen(1))
+			Expect(summary.Markdown()).To(ContainSubstring("### Warnings\n\n" +
+				"**1** invalid lines of the coverage profiles were skipped:\n\n" +
+				"- `../coverage/testdata/05-invalid-coverage.txt:3: example.com/app/main.go: bad format: " +
+				"couldn't parse \"EndCol\": strconv.Atoi: parsing \"x\": invalid syntax`\n\n---\n"))
+		})
+	})
+
+	Context("Branches", func() {
+		It("Should report the branch coverage next to the statement coverage", func() {
+			oldCov := coverage.NewCoverage(nil)
+			newCov, err := coverage.NewCoverageFromFile("../coverage/testdata/04-branch-count-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Source = "../coverage/testdata/source"
+			cfg.Threshold.Branch = 70
+
+			rep := report.New(&cfg, oldCov, newCov, []string{"example.com/source/branch/branch.go"})
+			Expect(rep.FileBranches).To(Equal(map[string]coverage.Branches{
+				"example.com/source/branch/branch.go": {Total: 15, Covered: 11},
+			}))
+			Expect(rep.PackageBranches).To(HaveKeyWithValue("example.com/source/branch", coverage.Branches{Total: 15, Covered: 11}))
+			Expect(rep.BranchCoveragePass.Value).To(BeTrue())
+
+			markdown := rep.Markdown()
+			Expect(markdown).To(ContainSubstring(`| Impacted Packages | Coverage Δ | :robot: | Branches |
+|-------------------|------------|---------|----------|
+| example.com/source/branch | 80.00% (**+80.00%**) | :star2: | 73.33% (11/15) |
+`))
+			Expect(markdown).To(ContainSubstring(`| 20 (+20) | 16 (+16) | 4 (+4) | :star2: | 73.33% (11/15) :white_check_mark: |`))
+			Expect(markdown).To(HaveSuffix("### Coverage Result: :white_check_mark: PASS"))
+		})
+
+		It("Should not analyze the branches by default", func() {
+			newCov, err := coverage.NewCoverageFromFile("../coverage/testdata/04-branch-count-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Source = "../coverage/testdata/source"
+
+			rep := report.New(&cfg, coverage.NewCoverage(nil), newCov, []string{"example.com/source/branch/branch.go"})
+			Expect(rep.FileBranches).To(BeNil())
+			Expect(rep.Markdown()).NotTo(ContainSubstring("Branches"))
+		})
+	})
+
+	Context("Risks", func() {
+		var (
+			newCov     *coverage.Coverage
+			newCovText string
+		)
+
+		BeforeEach(func() {
+			data, err := os.ReadFile("../coverage/testdata/04-branch-count-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCovText = string(data)
+			newCov, err = coverage.NewCoverageFromReader(strings.NewReader(newCovText), coverage.ParseOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Should list the riskiest uncovered functions", func() {
+			cfg := config.Default
+			cfg.Source = "../coverage/testdata/source"
+			cfg.Risk = config.Risk{Top: 2, Max: 3.1}
+
+			rep := report.New(&cfg, coverage.NewCoverage(nil), newCov, []string{"example.com/source/branch/branch.go"})
+			Expect(rep.Risks).To(HaveLen(5))
+			Expect(rep.Risks[0].Name).To(Equal("Kind"))
+			Expect(rep.Risks[0].Score).To(Equal(3.14))
+			Expect(rep.RiskPass).To(BeFalse())
+
+			markdown := rep.Markdown()
+			Expect(markdown).To(ContainSubstring(`### Riskiest uncovered changes
+
+| Function | File | Complexity | Coverage | Risk |
+|----------|------|------------|----------|------|
+| ` + "`Kind`" + ` | example.com/source/branch/branch.go:26 | 3 | 75.00% (3/4) | 3.14 :negative_squared_cross_mark: |
+| ` + "`Clamp`" + ` | example.com/source/branch/branch.go:4 | 3 | 80.00% (4/5) | 3.07 :white_check_mark: |
+
+---
+`))
+			Expect(markdown).To(HaveSuffix("### Coverage Result: :negative_squared_cross_mark: FAIL"))
+		})
+
+		It("Should only score the changed functions", func() {
+			oldCov, err := coverage.NewCoverageFromReader(strings.NewReader(strings.Replace(
+				newCovText, "branch.go:13.2,13.10", "branch.go:13.2,13.12", 1,
+			)), coverage.ParseOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Source = "../coverage/testdata/source"
+			cfg.Risk = config.Risk{Top: 2, Max: 3.1}
+
+			rep := report.New(&cfg, oldCov, newCov, []string{"example.com/source/branch/branch.go"})
+			Expect(rep.Risks).To(HaveLen(1))
+			Expect(rep.Risks[0].Name).To(Equal("Clamp"))
+			Expect(rep.RiskPass).To(BeTrue())
+			Expect(rep.Markdown()).NotTo(ContainSubstring("`Kind`"))
+		})
+
+		It("Should not score the functions by default", func() {
+			cfg := config.Default
+			cfg.Source = "../coverage/testdata/source"
+
+			rep := report.New(&cfg, coverage.NewCoverage(nil), newCov, []string{"example.com/source/branch/branch.go"})
+			Expect(rep.Risks).To(BeNil())
+			Expect(rep.RiskPass).To(BeTrue())
+		})
+	})
+
+	Context("Display names", func() {
+		It("Should only map the names when rendering", func() {
+			oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.RootPackage = "github.com/username/prioqueue"
+			cfg.Trim = "github.com/username"
+			cfg.Display.Rewrite = []config.RewriteRule{{Pattern: `^prioqueue/(\w+)\.go$`, Replace: "$1"}}
+			cfg.Threshold.File = 80
+
+			rep := report.New(&cfg, oldCov, newCov, []string{"github.com/username/prioqueue/min_heap.go"})
+			Expect(rep.ChangedFiles).To(Equal([]string{"github.com/username/prioqueue/min_heap.go"}))
+			Expect(rep.FileCoveragePass.Detail).To(HaveKeyWithValue("github.com/username/prioqueue/min_heap.go", true))
+
+			Expect(rep.Markdown()).To(ContainSubstring("| prioqueue | 90.20% (**-9.80%**) | :thumbsdown: |"))
+			Expect(rep.Markdown()).To(ContainSubstring("| min_heap | 80.77% (**-19.23%**) | 52 (+2) | 42 (-8) | 10 (+10) | :skull: | :white_check_mark: |"))
+			Expect(rep.JSON()).To(ContainSubstring(`"github.com/username/prioqueue/min_heap.go"`))
+			Expect(rep.Cobertura()).To(ContainSubstring(`filename="min_heap"`))
+
+			Expect(newCov.Files).To(HaveKey("github.com/username/prioqueue/min_heap.go"))
+		})
+	})
+
+	Context("Links", func() {
+		It("Should link the files, packages and uncovered lines", func() {
+			oldCov, err := coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err := coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.RootPackage = "github.com/username/prioqueue"
+			cfg.Tree.Enabled = true
+			cfg.Display.Links = config.Links{
+				Repo:    "username/prioqueue",
+				SHA:     "abc123",
+				File:    "https://github.com/{repo}/blob/{sha}/{path}",
+				Package: "https://github.com/{repo}/tree/{sha}/{path}",
+				Lines:   "https://github.com/{repo}/blob/{sha}/{path}#L{line}-L{end}",
+				Report:  "https://example.com/coverage.html",
+			}
+
+			rep := report.New(&cfg, oldCov, newCov, []string{"github.com/username/prioqueue/min_heap.go"})
+			actual := rep.Markdown()
+
+			Expect(actual).To(ContainSubstring("| [github.com/username/prioqueue](https://github.com/username/prioqueue/tree/abc123/) | 90.20% (**-9.80%**) |"))
+			Expect(actual).To(ContainSubstring("[Full coverage report](https://example.com/coverage.html)\n"))
+			Expect(actual).To(ContainSubstring("| [github.com/username/prioqueue/min_heap.go](https://github.com/username/prioqueue/blob/abc123/min_heap.go) | 80.77% (**-19.23%**) |"))
+			Expect(actual).To(ContainSubstring(`<a href="https://github.com/username/prioqueue/blob/abc123/min_heap.go"><code>min_heap.go</code></a>`))
+			Expect(actual).To(ContainSubstring(`### Uncovered lines
+
+- [github.com/username/prioqueue/min_heap.go](https://github.com/username/prioqueue/blob/abc123/min_heap.go): ` +
+				"[42-46](https://github.com/username/prioqueue/blob/abc123/min_heap.go#L42-L46), "))
+		})
+	})
+
+	Context("Comment size", func() {
+		// profile returns a profile of files in two packages, the files of
+		// the new one missing more statements as their number grows.
+		profile := func(regressed bool) *coverage.Coverage {
+			var b strings.Builder
+
+			b.WriteString("mode: set\n")
+
+			for i := range 40 {
+				missed := 0
+				if regressed {
+					missed = i % 10
+				}
+
+				name := fmt.Sprintf("example.com/repo/pkg%d/file%02d.go", i%2, i)
+				_, _ = fmt.Fprintf(&b, "%s:1.1,2.1 %d 1\n", name, 10-missed)
+				_, _ = fmt.Fprintf(&b, "%s:3.1,4.1 %d 0\n", name, missed)
+			}
+
+			cov, err := coverage.NewCoverageFromReader(strings.NewReader(b.String()), coverage.ParseOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			return cov
+		}
+
+		It("Should keep the failed and most regressed files", func() {
+			oldCov, newCov := profile(false), profile(true)
+
+			cfg := config.Default
+			cfg.Threshold.File = 50
+			cfg.Comment.Limit = 2000
+
+			rep := report.New(&cfg, oldCov, newCov, report.GetChangedFiles(oldCov, newCov, nil))
+			actual := rep.Markdown()
+
+			Expect(len(actual)).To(BeNumerically("<=", 2000))
+			Expect(actual).To(HavePrefix("## Coverage Percentage 55.00%"))
+			Expect(actual).To(ContainSubstring("| example.com/repo/pkg1 |"))
+			Expect(actual).To(ContainSubstring("| example.com/repo/pkg1/file09.go | 10.00% (**-90.00%**) |"))
+			Expect(actual).NotTo(ContainSubstring("file11.go"))
+			Expect(actual).To(MatchRegexp(`_\d+ more files omitted to fit the size limit of the comment._`))
+			Expect(actual).To(HaveSuffix("**Failed checks:** file coverage\n\n---\n### Coverage Result: :negative_squared_cross_mark: FAIL"))
+
+			cfg.Comment.Platform = config.PlatformNone
+			cfg.Comment.Limit = 0
+			Expect(rep.Markdown()).To(ContainSubstring("file11.go"))
+		})
+
+		It("Should keep the failed and least covered files of a summary", func() {
+			cfg := config.Default
+			cfg.Threshold.File = 50
+			cfg.Comment.Limit = 1200
+
+			summary := report.NewSummary(&cfg, profile(true))
+			actual := summary.Markdown()
+
+			Expect(len(actual)).To(BeNumerically("<=", 1200))
+			Expect(actual).To(ContainSubstring("| example.com/repo/pkg1/file09.go | 10.00% | 10 | 1 | 9 |"))
+			Expect(actual).NotTo(ContainSubstring("file10.go"))
+			Expect(actual).To(MatchRegexp(`_\d+ more files omitted to fit the size limit of the comment._`))
+			Expect(actual).To(HaveSuffix("### Coverage Result: :negative_squared_cross_mark: FAIL"))
+		})
+
+		It("Should note the omitted sections", func() {
+			oldCov, newCov := profile(false), profile(true)
+
+			cfg := config.Default
+			cfg.Tree.Enabled = true
+			cfg.Comment.Limit = 2000
+
+			actual := report.New(&cfg, oldCov, newCov, report.GetChangedFiles(oldCov, newCov, nil)).Markdown()
+
+			Expect(len(actual)).To(BeNumerically("<=", 2000))
+			Expect(actual).NotTo(ContainSubstring("Coverage tree"))
+			Expect(actual).To(ContainSubstring("_Sections omitted to fit the size limit of the comment: coverage tree._"))
+		})
+
+		It("Should truncate the report when even its shortest form is too big", func() {
+			oldCov, newCov := profile(false), profile(true)
+
+			cfg := config.Default
+			cfg.Comment.Limit = 300
+
+			actual := report.New(&cfg, oldCov, newCov, report.GetChangedFiles(oldCov, newCov, nil)).Markdown()
+
+			Expect(len(actual)).To(BeNumerically("<=", 300))
+			Expect(actual).To(HavePrefix("## Coverage Percentage 55.00%"))
+			Expect(actual).To(HaveSuffix("\n\n_Truncated to fit the size limit of the comment._"))
+
+			cfg.Comment.Limit = 10
+			Expect(report.NewSummary(&cfg, newCov).Markdown()).To(Equal("## Coverag"))
+		})
+	})
+
+	Context("Compare", func() {
+		var oldCov, newCov *coverage.Coverage
+
+		BeforeEach(func() {
+			var err error
+
+			oldCov, err = coverage.NewCoverageFromFile("testdata/01-old-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			newCov, err = coverage.NewCoverageFromFile("testdata/01-new-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Should compare the changed files", func() {
+			res, err := report.Compare(context.Background(), oldCov, newCov, report.Options{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(res.Empty()).To(BeFalse())
+			Expect(res.Passed()).To(BeTrue())
+			Expect(res.Total()).To(Equal(report.Comparison{
+				Old: report.Statements{Total: 100, Covered: 100},
+				New: report.Statements{Total: 102, Covered: 92, Missed: 10},
+			}))
+
+			Expect(res.Files()).To(Equal([]report.FileResult{{
+				Name:   "github.com/username/prioqueue/min_heap.go",
+				Change: report.ChangeModified,
+				Comparison: report.Comparison{
+					Old: report.Statements{Total: 50, Covered: 50},
+					New: report.Statements{Total: 52, Covered: 42, Missed: 10},
+				},
+			}}))
+
+			Expect(res.Packages()).To(HaveLen(1))
+			Expect(res.Packages()[0].Path).To(Equal("github.com/username/prioqueue"))
+		})
+
+		It("Should exclude the generated files and ignored code like the command line", func() {
+			sourceCov, err := coverage.NewCoverageFromFile("../coverage/testdata/03-source-coverage.txt")
+			Expect(err).ToNot(HaveOccurred())
+
+			cfg := config.Default
+			cfg.Source = "../coverage/testdata/source"
+			cfg.Exclude.Generated, cfg.Exclude.Directives = true, true
+
+			res, err := report.Compare(context.Background(), coverage.NewCoverage(nil), sourceCov, report.Options{Config: &cfg})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Total().New).To(Equal(report.Statements{Total: 7, Covered: 6, Missed: 1}))
+			Expect(res.Files()).To(HaveLen(1))
+			Expect(sourceCov.TotalStmt).To(Equal(12), "the input must not be modified")
+		})
+
+		It("Should only read the package names from the source with a resolver", func() {
+			named := coverage.NewCoverage([]coverage.Profile{{
+				FileName: "example.com/source/named/other.go",
+				Blocks:   []coverage.ProfileBlock{{StartLine: 3, StartCol: 20, EndLine: 3, EndCol: 30, NumStmt: 1, ExecCount: 1}},
+			}})
+
+			cfg := config.Default
+			cfg.Source = "../coverage/testdata/source"
+
+			res, err := report.Compare(context.Background(), coverage.NewCoverage(nil), named, report.Options{Config: &cfg})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Packages()[0].Name).To(Equal("named"))
+
+			res, err = report.Compare(context.Background(), coverage.NewCoverage(nil), named, report.Options{
+				Config:   &cfg,
+				Packages: coverage.NewPackages(report.Sources(&cfg)...),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Packages()[0].Name).To(Equal("other"))
+		})
+
+		It("Should not modify the coverages", func() {
+			files := make([]string, 0, len(newCov.Files))
+			for name := range newCov.Files {
+				files = append(files, name)
+			}
+
+			cfg := config.Default
+			cfg.Trim = "github.com/username/prioqueue"
+
+			res, err := report.Compare(context.Background(), oldCov, newCov, report.Options{Config: &cfg})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(newCov.Files).To(HaveLen(len(files)))
+			for _, name := range files {
+				Expect(newCov.Files).To(HaveKey(name))
+			}
+
+			// The names are only trimmed in the rendered reports.
+			Expect(res.Files()[0].Name).To(Equal("github.com/username/prioqueue/min_heap.go"))
+
+			var b strings.Builder
+			Expect(res.WriteMarkdown(&b)).To(Succeed())
+			Expect(b.String()).To(ContainSubstring("| min_heap.go |"))
+		})
+
+		It("Should return copies", func() {
+			res, err := report.Compare(context.Background(), oldCov, newCov, report.Options{})
+			Expect(err).ToNot(HaveOccurred())
+
+			res.Files()[0].Name = "changed"
+			Expect(res.Files()[0].Name).To(Equal("github.com/username/prioqueue/min_heap.go"))
+		})
+
+		It("Should check the thresholds", func() {
+			cfg := config.Default
+			cfg.Threshold.File = 90
+
+			res, err := report.Compare(context.Background(), oldCov, newCov, report.Options{Config: &cfg})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(res.Passed()).To(BeFalse())
+			Expect(res.Failures()).To(Equal([]string{report.CheckFile}))
+			Expect(res.Files()[0].Check).To(Equal(report.Check{Checked: true}))
+		})
+
+		It("Should only compare the given files", func() {
+			res, err := report.Compare(context.Background(), oldCov, newCov, report.Options{ChangedFiles: []string{}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Empty()).To(BeTrue())
+		})
+
+		It("Should render JSON", func() {
+			res, err := report.Compare(context.Background(), oldCov, newCov, report.Options{})
+			Expect(err).ToNot(HaveOccurred())
+
+			var b strings.Builder
+			Expect(res.Write(&b, "json")).To(Succeed())
+			Expect(json.Valid([]byte(b.String()))).To(BeTrue())
+
+			Expect(res.Write(&b, "yaml")).To(MatchError(ContainSubstring("yaml")))
+		})
+
+		It("Should stop when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := report.Compare(ctx, oldCov, newCov, report.Options{})
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("Should reject an invalid config", func() {
+			cfg := config.Default
+			cfg.Threshold.File = 120
+
+			_, err := report.Compare(context.Background(), oldCov, newCov, report.Options{Config: &cfg})
+			Expect(err).To(MatchError(config.ErrThresholdNotInRange))
+		})
+	})
 })
+
+// percent returns a pointer to a threshold, e.g. of a module.
+func percent(v int) *int {
+	return &v
+}
@@ -0,0 +1,168 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// FunctionRisk is the risk score of a changed function.
+type FunctionRisk struct {
+	File string
+	coverage.Function
+	Score float64
+}
+
+// hasRisk tells whether the risk of the functions is analyzed.
+func hasRisk(conf *config.Config) bool {
+	return conf.Risk.Top > 0 || conf.Risk.Max > 0
+}
+
+// functionRisks scores the changed functions whose source is found in the
+// modules, riskiest first: the functions of the added files, and the ones
+// overlapping a block that differs from the old coverage.
+func functionRisks(conf *config.Config, oldCov, newCov *coverage.Coverage, changedFiles []string) []FunctionRisk {
+	var (
+		res  = make([]FunctionRisk, 0)
+		srcs = Sources(conf)
+	)
+
+	for _, src := range srcs {
+		src.Directives = conf.Exclude.Directives
+	}
+
+	for _, file := range changedFiles {
+		p, ok := newCov.Files[file]
+		if !ok {
+			continue
+		}
+
+		changed := changedBlocks(oldCov.Files[file], p)
+
+		for _, src := range srcs {
+			functions, ok := src.Functions(p)
+			if !ok {
+				continue
+			}
+
+			for _, f := range functions {
+				if !overlaps(f, changed) {
+					continue
+				}
+
+				res = append(res, FunctionRisk{File: file, Function: f, Score: roundFloat(f.Risk(), 2)})
+			}
+
+			break
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+
+		return res[i].File < res[j].File || res[i].File == res[j].File && res[i].StartLine < res[j].StartLine
+	})
+
+	return res
+}
+
+// changedBlocks returns the blocks of the new profile missing from the old
+// one, i.e. every block of an added file.
+func changedBlocks(oldProfile, newProfile coverage.Profile) []coverage.ProfileBlock {
+	old := make(map[coverage.ProfileBlock]bool, len(oldProfile.Blocks))
+	for _, b := range oldProfile.Blocks {
+		old[withoutCount(b)] = true
+	}
+
+	var res []coverage.ProfileBlock
+
+	for _, b := range newProfile.Blocks {
+		if !old[withoutCount(b)] {
+			res = append(res, b)
+		}
+	}
+
+	return res
+}
+
+// withoutCount returns the block without execution count, comparing like
+// coverage.ProfileBlock.Equal.
+func withoutCount(b coverage.ProfileBlock) coverage.ProfileBlock {
+	b.ExecCount = 0
+	return b
+}
+
+// overlaps tells whether one of the blocks overlaps the lines of f.
+func overlaps(f coverage.Function, blocks []coverage.ProfileBlock) bool {
+	for _, b := range blocks {
+		if b.StartLine <= f.EndLine && b.EndLine >= f.StartLine {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRisk tells whether every function scores at most the maximum risk.
+func checkRisk(maxRisk float64, risks []FunctionRisk) bool {
+	for _, r := range risks {
+		if maxRisk > 0 && r.Score > maxRisk {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *Report) addRisks(report *strings.Builder) {
+	addRisks(report, r.conf, r.symbols, r.names, r.Risks)
+}
+
+// addRisks lists the riskiest functions with uncovered statements.
+func addRisks(
+	report *strings.Builder, conf *config.Config, symbols config.Symbols, names *config.DisplayNames, risks []FunctionRisk,
+) {
+	var (
+		maxRisk = conf.Risk.Max
+		rows    []FunctionRisk
+	)
+
+	for _, risk := range risks {
+		if len(rows) == conf.Risk.Top {
+			break
+		}
+
+		if risk.CoveredStmt < risk.TotalStmt {
+			rows = append(rows, risk)
+		}
+	}
+
+	if len(rows) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintln(report, "### Riskiest uncovered changes")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintln(report, "| Function | File | Complexity | Coverage | Risk |")
+	_, _ = fmt.Fprintln(report, "|----------|------|------------|----------|------|")
+
+	for _, risk := range rows {
+		score := fmt.Sprintf("%.2f", risk.Score)
+		if maxRisk > 0 {
+			score += " " + passSymbol(symbols, risk.Score <= maxRisk)
+		}
+
+		position := fmt.Sprintf("%s:%d", names.Name(risk.File), risk.StartLine)
+
+		_, _ = fmt.Fprintf(report, "| `%s` | %s | %d | %.2f%% (%d/%d) | %s |\n",
+			risk.Name, link(position, names.LinesURL(risk.File, risk.StartLine, risk.EndLine)), risk.Complexity,
+			risk.Percent(), risk.CoveredStmt, risk.TotalStmt, score)
+	}
+
+	_, _ = fmt.Fprintln(report)
+}
@@ -4,3 +4,29 @@ type CoveragePass struct {
 	Value  bool
 	Detail map[string]bool
 }
+
+// Names of the checks returned by Failures, in the order they are returned.
+const (
+	CheckTotal     = "total coverage"
+	CheckPackage   = "package coverage"
+	CheckFile      = "file coverage"
+	CheckDirectory = "directory coverage"
+	CheckBranch    = "branch coverage"
+	CheckRisk      = "function risk"
+	CheckModules   = "module coverage"
+)
+
+var checks = []string{CheckTotal, CheckPackage, CheckFile, CheckDirectory, CheckBranch, CheckRisk, CheckModules}
+
+// failures returns the checks not passed, in the order of checks.
+func failures(passed map[string]bool) []string {
+	var res []string
+
+	for _, check := range checks {
+		if pass, ok := passed[check]; ok && !pass {
+			res = append(res, check)
+		}
+	}
+
+	return res
+}
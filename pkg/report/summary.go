@@ -0,0 +1,356 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// Summary is the coverage of a single profile checked against the
+// thresholds, when there is no baseline to compare it with.
+type Summary struct {
+	Coverage *coverage.Coverage
+	Files    []string
+	Packages []string
+	// PackageNames holds the name of the packages, keyed by import path. They
+	// are derived from the import paths, unless read from the source by a
+	// coverage.Packages resolver.
+	PackageNames map[string]string
+
+	PackageCoveragePass   CoveragePass
+	FileCoveragePass      CoveragePass
+	DirectoryCoveragePass CoveragePass
+	BranchCoveragePass    CoveragePass
+	TotalCoveragePass     bool
+
+	// FileBranches and PackageBranches hold the approximated branch
+	// coverage of the packages and their files, when enabled.
+	FileBranches    map[string]coverage.Branches `json:",omitempty"`
+	PackageBranches map[string]coverage.Branches `json:",omitempty"`
+
+	// Modules holds the coverage of each configured module, checked against
+	// its total threshold.
+	Modules []ModuleCoverage `json:",omitempty"`
+
+	// Risks holds the risk score of every function, riskiest first, when
+	// enabled, and RiskPass checks them against the maximum risk.
+	Risks    []FunctionRisk `json:",omitempty"`
+	RiskPass bool
+
+	// Warnings holds the invalid lines of the profile skipped by lenient
+	// parsing.
+	Warnings []coverage.Diagnostic `json:",omitempty"`
+
+	conf    *config.Config
+	symbols config.Symbols
+	names   *config.DisplayNames
+}
+
+// NewSummary checks every file and package of the coverage against the
+// thresholds of the config.
+func NewSummary(conf *config.Config, cov *coverage.Coverage) *Summary {
+	files := make([]string, 0, len(cov.Files))
+	for name := range cov.Files {
+		files = append(files, name)
+	}
+
+	sort.Strings(files)
+	packages := changedPackages(files)
+
+	s := &Summary{
+		Coverage:              cov,
+		Files:                 files,
+		Packages:              packages,
+		PackageNames:          coverage.NewPackages().Names(packages),
+		PackageCoveragePass:   checkPackageCoverage(conf, cov, packages),
+		FileCoveragePass:      checkFileCoverage(conf, cov, files),
+		DirectoryCoveragePass: checkDirectoryCoverage(conf, cov, files),
+		TotalCoveragePass:     isCoveragePassed(conf.Threshold.Total, cov.Percent()),
+		Modules:               moduleCoverages(conf, coverage.NewCoverage(nil), cov, files),
+		Warnings:              warnings(coverage.NewCoverage(nil), cov),
+		conf:                  conf,
+		symbols:               conf.Symbols.Resolve(),
+		names:                 displayNames(conf),
+	}
+
+	if hasBranches(conf) {
+		s.FileBranches, s.PackageBranches = branchCoverage(conf, cov, packages)
+	}
+
+	s.BranchCoveragePass = checkBranchCoverage(conf, s.FileBranches, files)
+
+	if hasRisk(conf) {
+		// without a baseline, every function is new
+		s.Risks = functionRisks(conf, coverage.NewCoverage(nil), cov, files)
+	}
+
+	s.RiskPass = checkRisk(conf.Risk.Max, s.Risks)
+
+	return s
+}
+
+// Passed tells whether the coverage passes every check of the config.
+func (s *Summary) Passed() bool {
+	return len(s.Failures()) == 0
+}
+
+// Failures returns the checks the coverage fails.
+func (s *Summary) Failures() []string {
+	return failures(map[string]bool{
+		CheckTotal:     s.TotalCoveragePass,
+		CheckPackage:   s.PackageCoveragePass.Value,
+		CheckFile:      s.FileCoveragePass.Value,
+		CheckDirectory: s.DirectoryCoveragePass.Value,
+		CheckBranch:    s.BranchCoveragePass.Value,
+		CheckRisk:      s.RiskPass,
+		CheckModules:   modulesPass(s.Modules),
+	})
+}
+
+// Markdown renders the summary as markdown, shortened to fit the size limit
+// of the comment, see config.Comment.
+func (s *Summary) Markdown() string {
+	res := s.markdown(s.Packages, s.Files)
+
+	if limit := s.conf.Comment.MaxSize(); limit > 0 && len(res) > limit {
+		return fitLimit(s.budgetedMarkdown(limit), limit)
+	}
+
+	return res
+}
+
+// markdown renders the summary holding the given packages and files, in
+// that order.
+func (s *Summary) markdown(packages, files []string) string {
+	var (
+		report    = new(strings.Builder)
+		shortened = len(packages) < len(s.Packages) || len(files) < len(s.Files)
+	)
+
+	_, _ = fmt.Fprintf(report, "## Coverage Percentage %.2f%%\n", s.Coverage.Percent())
+	_, _ = fmt.Fprintln(report, "### Coverage without a baseline to compare with")
+	_, _ = fmt.Fprintln(report)
+
+	s.addTable(report, s.packagesTable(packages))
+
+	_, _ = fmt.Fprintln(report)
+
+	addOmitted(report, len(s.Packages)-len(packages), "packages")
+	addReportLink(report, s.names)
+
+	if len(s.Modules) > 0 {
+		s.addTable(report, s.modulesTable())
+		_, _ = fmt.Fprintln(report)
+	}
+
+	if s.conf.Risk.Top > 0 {
+		addRisks(report, s.conf, s.symbols, s.names, s.Risks)
+	}
+
+	if len(s.Warnings) > 0 {
+		addWarnings(report, s.Warnings)
+	}
+
+	_, _ = fmt.Fprintln(report, "---")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintln(report, "<details>")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintln(report, "<summary>Coverage by file</summary>")
+	_, _ = fmt.Fprintln(report)
+
+	s.addTable(report, s.filesTable(files))
+
+	_, _ = fmt.Fprintln(report)
+	addOmitted(report, len(s.Files)-len(files), "files")
+	_, _ = fmt.Fprint(report, "</details>")
+
+	if hasResult(s.conf) {
+		if shortened {
+			addFailures(report, s.Failures())
+		}
+
+		_, _ = fmt.Fprintln(report)
+		_, _ = fmt.Fprintln(report, "\n---")
+
+		result, symbol := "FAIL", s.symbols.Fail
+		if s.Passed() {
+			result, symbol = "PASS", s.symbols.Pass
+		}
+
+		if symbol != "" {
+			result = symbol + " " + result
+		}
+
+		_, _ = fmt.Fprintf(report, "### Coverage Result: %s", result)
+	}
+
+	return report.String()
+}
+
+func (s *Summary) packagesTable(packages []string) summaryTable {
+	covs := s.Coverage.ByPackage()
+
+	return summaryTable{
+		title: "Packages",
+		names: packages,
+		get: func(pkg string) (coverage.Profile, string) {
+			cov := covs[pkg]
+			profile := coverage.Profile{TotalStmt: cov.TotalStmt, CoveredStmt: cov.CoveredStmt, MissedStmt: cov.MissedStmt}
+
+			return profile, packageLabel(s.names, s.PackageNames, pkg)
+		},
+		pass:      s.PackageCoveragePass,
+		threshold: func(t config.Threshold) int { return t.Package },
+		branches:  s.PackageBranches,
+	}
+}
+
+func (s *Summary) modulesTable() summaryTable {
+	var (
+		modules = make(map[string]ModuleCoverage, len(s.Modules))
+		names   = make([]string, 0, len(s.Modules))
+		pass    = CoveragePass{Value: modulesPass(s.Modules), Detail: make(map[string]bool)}
+	)
+
+	for _, m := range s.Modules {
+		modules[m.Path] = m
+		names = append(names, m.Path)
+		pass.Detail[m.Path] = m.TotalCoveragePass
+	}
+
+	return summaryTable{
+		title: "Modules",
+		names: names,
+		get: func(path string) (coverage.Profile, string) {
+			m := modules[path]
+			return coverage.Profile{TotalStmt: m.TotalStmt, CoveredStmt: m.CoveredStmt, MissedStmt: m.MissedStmt}, path
+		},
+		pass:      pass,
+		threshold: func(t config.Threshold) int { return t.Total },
+	}
+}
+
+func (s *Summary) filesTable(files []string) summaryTable {
+	return summaryTable{
+		title: "Files",
+		names: files,
+		get: func(name string) (coverage.Profile, string) {
+			return s.Coverage.Files[name], link(s.names.Name(name), s.names.FileURL(name))
+		},
+		pass:       s.FileCoveragePass,
+		threshold:  func(t config.Threshold) int { return t.File },
+		branches:   s.FileBranches,
+		branchPass: s.BranchCoveragePass.Detail,
+	}
+}
+
+// summaryTable is a table of the coverage of packages or files.
+type summaryTable struct {
+	title string
+	names []string
+	// get returns the statements and the label of a package or file.
+	get       func(name string) (coverage.Profile, string)
+	pass      CoveragePass
+	threshold func(t config.Threshold) int
+	// branches and branchPass hold the branch coverage and its check, if
+	// any.
+	branches   map[string]coverage.Branches
+	branchPass map[string]bool
+}
+
+func (s *Summary) addTable(report *strings.Builder, table summaryTable) {
+	_, _ = fmt.Fprintln(report, strings.Join(s.table(table), "\n"))
+}
+
+// table returns the lines of a table: its header, its separator and a row
+// per package or file.
+func (s *Summary) table(table summaryTable) []string {
+	var (
+		header    = fmt.Sprintf("| %s | Coverage | Total | Covered | Missed |", table.title)
+		separator = fmt.Sprintf("|%s|----------|-------|---------|--------|", strings.Repeat("-", len(table.title)+2))
+		hasCheck  = hasThreshold(s.conf, table.threshold)
+	)
+
+	if hasCheck {
+		header += " Pass |"
+		separator += "------|"
+	}
+
+	if table.branches != nil {
+		header += " Branches |"
+		separator += "----------|"
+	}
+
+	lines := []string{header, separator}
+
+	for _, name := range table.names {
+		var (
+			p, label = table.get(name)
+			row      = fmt.Sprintf("| %s | %.2f%% | %d | %d | %d |", label, p.CoveragePercent(), p.TotalStmt, p.CoveredStmt, p.MissedStmt)
+		)
+
+		if hasCheck {
+			row += fmt.Sprintf(" %s |", passSymbol(s.symbols, table.pass.Detail[name]))
+		}
+
+		if table.branches != nil {
+			var pass *bool
+			if v, ok := table.branchPass[name]; ok {
+				pass = &v
+			}
+
+			b, ok := table.branches[name]
+			row += fmt.Sprintf(" %s |", branchLabel(s.symbols, b, ok, pass))
+		}
+
+		lines = append(lines, row)
+	}
+
+	return lines
+}
+
+// JSON returns the summary as JSON, see WriteJSON.
+func (s *Summary) JSON() (string, error) {
+	return renderJSON(s.WriteJSON)
+}
+
+// WriteJSON renders the summary as JSON.
+func (s *Summary) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+
+	return enc.Encode(s)
+}
+
+// Write renders the summary in the given output format.
+func (s *Summary) Write(w io.Writer, format string) error {
+	content, err := s.Render(format)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, content)
+
+	return err
+}
+
+// Render returns the summary in the given output format.
+func (s *Summary) Render(format string) (string, error) {
+	switch format {
+	case FormatMarkdown:
+		return s.Markdown(), nil
+	case FormatJSON:
+		return s.JSON()
+	case FormatCobertura:
+		return cobertura(s.Coverage, s.names)
+	case FormatHTML:
+		return s.HTML(), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %q", format)
+	}
+}
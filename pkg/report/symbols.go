@@ -0,0 +1,58 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+)
+
+// scoreSymbol returns the symbol of the first band in the scale matching the
+// coverage change together with the formatted change itself.
+func scoreSymbol(symbols config.Symbols, newPercent, oldPercent float64) (symbol, diffStr string) {
+	diff := newPercent - oldPercent
+	if diff == 0 {
+		return "", symbols.Unchanged
+	}
+
+	diffStr = fmt.Sprintf("**%+.2f%%**", diff)
+
+	for _, band := range symbols.Scale {
+		if !band.Matches(diff) {
+			continue
+		}
+
+		if band.Step <= 0 {
+			return band.Symbol, diffStr
+		}
+
+		n := int(math.Abs(diff) / band.Step)
+		if band.Max > 0 {
+			n = min(n, band.Max)
+		}
+
+		return strings.TrimSpace(strings.Repeat(band.Symbol+" ", max(n, 1))), diffStr
+	}
+
+	return "", diffStr
+}
+
+// passSymbol falls back to a plain word when the preset defines no symbol, so
+// the pass columns are never left blank.
+func passSymbol(symbols config.Symbols, val bool) string {
+	switch {
+	case val && symbols.Pass != "":
+		return symbols.Pass
+	case val:
+		return "PASS"
+	case symbols.Fail != "":
+		return symbols.Fail
+	default:
+		return "FAIL"
+	}
+}
+
+func (r *Report) hasTrend() bool {
+	return len(r.symbols.Scale) > 0
+}
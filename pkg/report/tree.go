@@ -0,0 +1,219 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/config"
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+// TreeNode is a level of the coverage tree with its coverage change. Only the
+// levels holding changed files are part of the tree.
+type TreeNode struct {
+	Name        string
+	Path        string
+	Kind        string
+	OldPercent  float64
+	NewPercent  float64
+	TotalStmt   int
+	CoveredStmt int
+	MissedStmt  int
+	Children    []*TreeNode `json:",omitempty"`
+}
+
+// buildTree merges the old and new coverage trees below root, keeping the
+// levels holding changed files up to depth levels below the module. The tree
+// spans the files of both coverages, so that the removed files and packages
+// are part of it.
+func buildTree(root string, oldCov, newCov *coverage.Coverage, changedFiles []string, depth int) *TreeNode {
+	var (
+		tree     = unionCoverage(oldCov, newCov).Tree(root)
+		oldNodes = treeNodes(oldCov.Tree(tree.Path))
+		newNodes = treeNodes(newCov.Tree(tree.Path))
+		impacted = impactedPaths(changedFiles)
+	)
+
+	var merge func(node *coverage.Tree, level int) *TreeNode
+
+	merge = func(node *coverage.Tree, level int) *TreeNode {
+		res := &TreeNode{
+			Name: node.Name,
+			Path: node.Path,
+			Kind: node.Kind,
+		}
+
+		if cur, ok := newNodes[node.Path]; ok {
+			res.NewPercent = cur.Percent()
+			res.TotalStmt, res.CoveredStmt, res.MissedStmt = cur.TotalStmt, cur.CoveredStmt, cur.MissedStmt
+		}
+
+		if old, ok := oldNodes[node.Path]; ok {
+			res.OldPercent = old.Percent()
+		}
+
+		if depth > 0 && level >= depth {
+			return res
+		}
+
+		for _, child := range node.Children {
+			if impacted[child.Path] {
+				res.Children = append(res.Children, merge(child, level+1))
+			}
+		}
+
+		return res
+	}
+
+	return merge(tree, 0)
+}
+
+// unionCoverage returns the coverage of the files of both coverages, the
+// new profile of the files found in both.
+func unionCoverage(oldCov, newCov *coverage.Coverage) *coverage.Coverage {
+	profiles := make([]coverage.Profile, 0, len(newCov.Files))
+
+	for _, p := range newCov.Files {
+		profiles = append(profiles, p)
+	}
+
+	for name, p := range oldCov.Files {
+		if _, ok := newCov.Files[name]; !ok {
+			profiles = append(profiles, p)
+		}
+	}
+
+	return coverage.NewCoverage(profiles)
+}
+
+// treeNodes indexes the nodes of the tree by path.
+func treeNodes(tree *coverage.Tree) map[string]*coverage.Tree {
+	res := make(map[string]*coverage.Tree)
+
+	tree.Walk(func(node *coverage.Tree) {
+		res[node.Path] = node
+	})
+
+	return res
+}
+
+// impactedPaths returns the changed files and all their parent directories.
+func impactedPaths(changedFiles []string) map[string]bool {
+	res := make(map[string]bool)
+
+	for _, file := range changedFiles {
+		for p := file; p != "." && p != "/" && !res[p]; p = coverage.PackagePath(p) {
+			res[p] = true
+		}
+	}
+
+	return res
+}
+
+// checkDirectoryCoverage checks every directory and package holding changed
+// files against the directory threshold of their module, regardless of the
+// depth shown in the report.
+func checkDirectoryCoverage(conf *config.Config, cov *coverage.Coverage, changedFiles []string) CoveragePass {
+	var res = CoveragePass{
+		Value:  true,
+		Detail: make(map[string]bool),
+	}
+
+	if !hasThreshold(conf, func(t config.Threshold) int { return t.Directory }) {
+		return res
+	}
+
+	impacted := impactedPaths(changedFiles)
+
+	cov.Tree(conf.RootPackage).Walk(func(node *coverage.Tree) {
+		if node.Kind != coverage.KindDirectory && node.Kind != coverage.KindPackage || !impacted[node.Path] {
+			return
+		}
+
+		threshold := conf.ThresholdOf(node.Path).Directory
+		if threshold <= 0 {
+			return
+		}
+
+		res.Detail[node.Path] = isCoveragePassed(threshold, node.Percent())
+		if !res.Detail[node.Path] {
+			res.Value = false
+		}
+	})
+
+	return res
+}
+
+func (r *Report) addTree(report *strings.Builder) {
+	_, _ = fmt.Fprintln(report, "<details>")
+	_, _ = fmt.Fprintln(report, "<summary>Coverage tree</summary>")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintln(report, "<ul>")
+	r.htmlTree().addNode(report, r.Tree)
+	_, _ = fmt.Fprintln(report, "</ul>")
+	_, _ = fmt.Fprintln(report, "</details>")
+	_, _ = fmt.Fprintln(report)
+}
+
+func (r *Report) htmlTree() htmlTree {
+	return htmlTree{symbols: r.symbols, names: r.names, pass: r.DirectoryCoveragePass, delta: true}
+}
+
+// htmlTree renders the coverage tree as nested HTML lists, collapsed below
+// the module.
+type htmlTree struct {
+	symbols config.Symbols
+	names   *config.DisplayNames
+	pass    CoveragePass
+	// delta shows the coverage change of every level, when there is a
+	// baseline to compare with.
+	delta bool
+}
+
+func (t htmlTree) addNode(report *strings.Builder, node *TreeNode) {
+	name := fmt.Sprintf("<code>%s</code>", html.EscapeString(node.Name))
+
+	url := t.names.PackageURL(node.Path)
+	if node.Kind == coverage.KindFile {
+		url = t.names.FileURL(node.Path)
+	}
+
+	if url != "" {
+		name = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), name)
+	}
+
+	label := fmt.Sprintf("%s %.2f%%", name, node.NewPercent)
+
+	if t.delta {
+		symbol, diffStr := scoreSymbol(t.symbols, node.NewPercent, node.OldPercent)
+
+		label += fmt.Sprintf(" (%s)", strings.ReplaceAll(html.EscapeString(diffStr), "**", ""))
+
+		if symbol != "" {
+			label += " " + symbol
+		}
+	}
+
+	if pass, ok := t.pass.Detail[node.Path]; ok {
+		label += " " + passSymbol(t.symbols, pass)
+	}
+
+	if len(node.Children) == 0 {
+		_, _ = fmt.Fprintf(report, "<li>%s</li>\n", label)
+		return
+	}
+
+	open := ""
+	if node.Kind == coverage.KindModule {
+		open = " open"
+	}
+
+	_, _ = fmt.Fprintf(report, "<li><details%s><summary>%s</summary>\n<ul>\n", open, label)
+
+	for _, child := range node.Children {
+		t.addNode(report, child)
+	}
+
+	_, _ = fmt.Fprintln(report, "</ul>\n</details></li>")
+}
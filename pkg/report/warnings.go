@@ -0,0 +1,84 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/willjunx/go-coverage-report/pkg/coverage"
+)
+
+const (
+	// maxWarnings limits the diagnostics listed in the markdown report.
+	maxWarnings = 10
+	// maxWarningField limits the characters of the file name and of the
+	// message of a diagnostic, which may hold a whole invalid line.
+	maxWarningField = 120
+)
+
+// warnings returns the diagnostics of the lines skipped while parsing the
+// old and the new coverage.
+func warnings(oldCov, newCov *coverage.Coverage) []coverage.Diagnostic {
+	if len(oldCov.Diagnostics)+len(newCov.Diagnostics) == 0 {
+		return nil
+	}
+
+	return append(append([]coverage.Diagnostic(nil), oldCov.Diagnostics...), newCov.Diagnostics...)
+}
+
+func (r *Report) addWarnings(report *strings.Builder) {
+	addWarnings(report, r.Warnings)
+}
+
+func addWarnings(report *strings.Builder, diags []coverage.Diagnostic) {
+	_, _ = fmt.Fprintln(report, "### Warnings")
+	_, _ = fmt.Fprintln(report)
+	_, _ = fmt.Fprintf(report, "**%d** invalid lines of the coverage profiles were skipped:\n", len(diags))
+	_, _ = fmt.Fprintln(report)
+
+	for i, d := range diags {
+		if i == maxWarnings {
+			_, _ = fmt.Fprintf(report, "- … and %d more\n", len(diags)-maxWarnings)
+			break
+		}
+
+		d.FileName, d.Message = truncate(d.FileName, maxWarningField), truncate(d.Message, maxWarningField)
+		_, _ = fmt.Fprintf(report, "- %s\n", inlineCode(d.String()))
+	}
+
+	_, _ = fmt.Fprintln(report)
+}
+
+// truncate shortens s to at most n characters, ending it with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-1]) + "…"
+}
+
+// inlineCode renders s as markdown inline code, delimited by more backticks
+// than the longest run of backticks within s.
+func inlineCode(s string) string {
+	var longest, run int
+
+	for _, r := range s {
+		if r != '`' {
+			run = 0
+			continue
+		}
+
+		run++
+		longest = max(longest, run)
+	}
+
+	if longest == 0 {
+		return "`" + s + "`"
+	}
+
+	fence := strings.Repeat("`", longest+1)
+
+	// the spaces keep backticks at either end apart from the fence
+	return fence + " " + s + " " + fence
+}
@@ -12,7 +12,6 @@ setup_env_variables()  {
   COVERAGE_FILE_NAME=${COVERAGE_FILE_NAME:-coverage.txt}
   SKIP_COMMENT=${SKIP_COMMENT:-false}
   COMMENT_TAG="<-- ${COMMENT_TAG:-Go Coverage Report} -->"
-  CONFIG_PATH="${CONFIG_PATH:-}"
 
   OLD_COVERAGE_PATH=.github/outputs/old-coverage.txt
   NEW_COVERAGE_PATH=.github/outputs/new-coverage.txt
@@ -109,7 +108,7 @@ check_coverage_result() {
   local source="$1"
   local fail_marker="$2"
 
-  if grep -Fxq "$fail_marker" "$source"; then
+  if grep -Eq "$fail_marker" "$source"; then
     echo "❌ Coverage check failed. Exiting with error."
     exit 1
   else
@@ -120,33 +119,32 @@ check_coverage_result() {
 main() {
   setup_env_variables
 
-  LAST_SUCCESSFUL_RUN_ID=$(gh run list --status=success --branch="$TARGET_BRANCH" --workflow="$GITHUB_BASELINE_WORKFLOW" --event=push --json=databaseId --limit=1 -q '.[] | .databaseId')
-  if [ -z "$LAST_SUCCESSFUL_RUN_ID" ]; then
-    echo "No successful run found on the target branch"
-    exit 0
-  fi
-
-  if ! check_coverage_artifact "$LAST_SUCCESSFUL_RUN_ID" "$COVERAGE_ARTIFACT_NAME"; then
-    echo "No Artifact $COVERAGE_ARTIFACT_NAME found on the target branch"
-    exit 0
-  fi
-
   start_group "Download code coverage results from current run"
   download_coverage_artifact "$GITHUB_RUN_ID" "$COVERAGE_ARTIFACT_NAME" "$COVERAGE_FILE_NAME" "$NEW_COVERAGE_PATH"
   end_group
 
-  start_group "Download code coverage results from target branch"
-  download_coverage_artifact "$LAST_SUCCESSFUL_RUN_ID" "$COVERAGE_ARTIFACT_NAME" "$COVERAGE_FILE_NAME" "$OLD_COVERAGE_PATH"
-  end_group
+  LAST_SUCCESSFUL_RUN_ID=$(gh run list --status=success --branch="$TARGET_BRANCH" --workflow="$GITHUB_BASELINE_WORKFLOW" --event=push --json=databaseId --limit=1 -q '.[] | .databaseId')
+  if [ -z "$LAST_SUCCESSFUL_RUN_ID" ]; then
+    echo "No successful run found on the target branch, reporting the coverage without a baseline"
+  elif ! check_coverage_artifact "$LAST_SUCCESSFUL_RUN_ID" "$COVERAGE_ARTIFACT_NAME"; then
+    echo "No Artifact $COVERAGE_ARTIFACT_NAME found on the target branch, reporting the coverage without a baseline"
+    LAST_SUCCESSFUL_RUN_ID=""
+  fi
 
-  start_group "Compare code coverage results"
-  REPORT=$(go-coverage-report \
-      -root="$ROOT_PACKAGE" \
-      -trim="$TRIM_PACKAGE" \
-      -config="$CONFIG_PATH" \
-      "$OLD_COVERAGE_PATH" \
-      "$NEW_COVERAGE_PATH")
-  end_group
+  # Options are read from the GO_COVERAGE_REPORT_* environment variables
+  if [ -n "$LAST_SUCCESSFUL_RUN_ID" ]; then
+    start_group "Download code coverage results from target branch"
+    download_coverage_artifact "$LAST_SUCCESSFUL_RUN_ID" "$COVERAGE_ARTIFACT_NAME" "$COVERAGE_FILE_NAME" "$OLD_COVERAGE_PATH"
+    end_group
+
+    start_group "Compare code coverage results"
+    REPORT=$(go-coverage-report "$OLD_COVERAGE_PATH" "$NEW_COVERAGE_PATH")
+    end_group
+  else
+    start_group "Summarize code coverage results"
+    REPORT=$(go-coverage-report summary "$NEW_COVERAGE_PATH")
+    end_group
+  fi
 
   if [ -z "$REPORT" ]; then
     echo "::notice::No coverage report to output"
@@ -172,7 +170,7 @@ main() {
   post_comment "$GITHUB_PULL_REQUEST_NUMBER" "$COVERAGE_COMMENT_PATH" "$COMMENT_TAG"
   end_group
 
-  check_coverage_result "$COVERAGE_COMMENT_PATH" "### Coverage Result: :negative_squared_cross_mark: FAIL"
+  check_coverage_result "$COVERAGE_COMMENT_PATH" "^### Coverage Result: (.* )?FAIL$"
 }
 
 main